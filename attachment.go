@@ -0,0 +1,291 @@
+// Package wcf_rpc_sdk
+// @Author Clover
+// @Data 2026/10/17 上午1:40:00
+// @Desc 附件下载队列，等待文件写入完成后返回结果
+package wcf_rpc_sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Clov614/wcf-rpc-sdk/internal/utils/imgutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultAttachmentWorkers = 4                // 默认并发下载数
+	DefaultAttachmentTimeout = 30 * time.Second // 默认单个附件等待写入完成的时间
+)
+
+const attachmentStableChecks = 3 // 连续多少次检查大小不变视为写入完成
+
+var (
+	attachmentPollInterval = 200 * time.Millisecond // 文件大小检查间隔
+	voicePollInterval      = time.Second            // 语音尚未写入 MediaMSG 数据库时的重试间隔
+)
+
+var (
+	ErrNotAttachment       = errors.New("the message has no attachment")
+	ErrAttachmentTimeout   = errors.New("attachment download timeout")
+	ErrAttachmentQueueFull = errors.New("attachment queue is full")
+)
+
+// AttachmentResult 附件下载结果
+type AttachmentResult struct {
+	MessageId uint64 `json:"message_id"`
+	Path      string `json:"path,omitempty"` // 附件的本地完整路径，语音为空
+	Data      []byte `json:"-"`              // 图片为解密后的数据，语音为 silk 数据，视频为封面缩略图，其它附件为空
+	FileExt   string `json:"file_ext,omitempty"`
+	Err       error  `json:"-"`
+}
+
+// attachmentReq 下载所需的消息字段，入队时复制，避免与消息的后续处理并发读写
+type attachmentReq struct {
+	id    uint64
+	typ   MsgType
+	thumb string
+	extra string
+}
+
+type attachmentJob struct {
+	req     attachmentReq
+	waiters []chan AttachmentResult
+}
+
+// AttachmentManager 附件下载队列：调用 FUNC_DOWNLOAD_ATTACH 后监视目标文件，直到大小稳定或超时
+// 同一消息在下载完成前重复提交时共享同一次下载
+type AttachmentManager struct {
+	cli       *Client
+	workers   int
+	timeout   time.Duration
+	poll      time.Duration // 文件大小检查间隔，创建时取自 attachmentPollInterval
+	voicePoll time.Duration // 语音入库重试间隔，创建时取自 voicePollInterval
+	jobs      chan *attachmentJob
+	startOnce sync.Once
+	stopOnce  sync.Once
+
+	mu       sync.Mutex
+	inflight map[uint64]*attachmentJob
+	closed   bool // 客户端已关闭，不再接受下载
+}
+
+func newAttachmentManager(cli *Client, workers int, timeout time.Duration) *AttachmentManager {
+	return &AttachmentManager{
+		cli:       cli,
+		workers:   workers,
+		timeout:   timeout,
+		poll:      attachmentPollInterval,
+		voicePoll: voicePollInterval,
+		jobs:      make(chan *attachmentJob, workers),
+		inflight:  make(map[uint64]*attachmentJob),
+	}
+}
+
+// hasAttachment 是否为可下载附件的消息
+func hasAttachment(msg *Message) bool {
+	switch msg.Type {
+	case MsgTypeImage, MsgTypeVideo, MsgTypeShortVideo, MsgTypeXMLFile, MsgTypeFile:
+		return msg.Extra != ""
+	case MsgTypeVoice:
+		return true
+	}
+	return false
+}
+
+// Download 提交下载，返回的通道在完成、失败或超时后收到一个结果；ctx 仅作用于排队等待
+func (am *AttachmentManager) Download(ctx context.Context, msg *Message) <-chan AttachmentResult {
+	return am.submit(ctx, msg, true)
+}
+
+// tryDownload 不阻塞地提交下载，队列已满时放弃，之后调用 Download 时再下载
+func (am *AttachmentManager) tryDownload(msg *Message) bool {
+	select {
+	case res := <-am.submit(am.cli.ctx, msg, false):
+		return !errors.Is(res.Err, ErrAttachmentQueueFull)
+	default:
+		return true
+	}
+}
+
+func (am *AttachmentManager) submit(ctx context.Context, msg *Message, block bool) <-chan AttachmentResult {
+	result := make(chan AttachmentResult, 1)
+	if msg == nil || !hasAttachment(msg) {
+		result <- AttachmentResult{Err: ErrNotAttachment}
+		return result
+	}
+	am.startOnce.Do(func() {
+		for i := 0; i < am.workers; i++ {
+			go am.work()
+		}
+	})
+
+	am.mu.Lock()
+	if am.closed {
+		am.mu.Unlock()
+		result <- AttachmentResult{MessageId: msg.MessageId, Err: am.cli.ctx.Err()}
+		return result
+	}
+	if job, ok := am.inflight[msg.MessageId]; ok {
+		job.waiters = append(job.waiters, result)
+		am.mu.Unlock()
+		return result
+	}
+	job := &attachmentJob{
+		req:     attachmentReq{id: msg.MessageId, typ: msg.Type, thumb: msg.Thumb, extra: msg.Extra},
+		waiters: []chan AttachmentResult{result},
+	}
+	am.inflight[msg.MessageId] = job
+	am.mu.Unlock()
+
+	if !block {
+		select {
+		case am.jobs <- job:
+		default:
+			am.finish(job, AttachmentResult{MessageId: job.req.id, Err: ErrAttachmentQueueFull})
+		}
+		return result
+	}
+	select {
+	case am.jobs <- job:
+	case <-ctx.Done():
+		am.finish(job, AttachmentResult{MessageId: job.req.id, Err: ctx.Err()})
+	case <-am.cli.ctx.Done():
+		am.finish(job, AttachmentResult{MessageId: job.req.id, Err: am.cli.ctx.Err()})
+	}
+	return result
+}
+
+func (am *AttachmentManager) work() {
+	for {
+		select {
+		case <-am.cli.ctx.Done():
+			am.stopOnce.Do(am.stop)
+			return
+		case job := <-am.jobs:
+			am.finish(job, am.download(job.req))
+		}
+	}
+}
+
+// stop 客户端关闭时结束所有未完成的下载，包括仍在队列中的任务
+func (am *AttachmentManager) stop() {
+	am.mu.Lock()
+	am.closed = true
+	jobs := am.inflight
+	am.inflight = make(map[uint64]*attachmentJob)
+	am.mu.Unlock()
+	for _, job := range jobs {
+		for _, w := range job.waiters {
+			w <- AttachmentResult{MessageId: job.req.id, Err: am.cli.ctx.Err()}
+		}
+	}
+}
+
+// finish 通知所有等待者，之后的提交会重新下载；已由 stop 结束的任务不再通知
+func (am *AttachmentManager) finish(job *attachmentJob, res AttachmentResult) {
+	am.mu.Lock()
+	if am.inflight[job.req.id] != job {
+		am.mu.Unlock()
+		return
+	}
+	delete(am.inflight, job.req.id)
+	waiters := job.waiters
+	am.mu.Unlock()
+	for _, w := range waiters {
+		w <- res
+	}
+}
+
+func (am *AttachmentManager) download(req attachmentReq) (res AttachmentResult) {
+	res.MessageId = req.id
+	ctx, cancel := context.WithTimeout(am.cli.ctx, am.timeout)
+	defer cancel()
+	if req.typ == MsgTypeVoice { // 语音不落盘，直接从数据库读取
+		res.Data, res.Err = am.cli.voiceSilk(ctx, req.id, am.voicePoll)
+		if errors.Is(res.Err, ErrVoiceNotReady) {
+			res.Err = fmt.Errorf("%w: %w", ErrAttachmentTimeout, res.Err)
+		}
+		res.FileExt = ".silk"
+		return res
+	}
+
+	res.Path = filepath.ToSlash(req.extra)
+	if _, err := am.cli.wxClient.DownloadAttachCtx(ctx, req.id, req.thumb, req.extra); err != nil {
+		res.Err = fmt.Errorf("wxClient.DownloadAttach: %w", err)
+		return res
+	}
+	if err := waitFileStable(ctx, req.extra, am.poll); err != nil {
+		res.Err = err
+		return res
+	}
+	res.FileExt = filepath.Ext(req.extra)
+	if (req.typ == MsgTypeVideo || req.typ == MsgTypeShortVideo) && req.thumb != "" {
+		data, err := readThumb(req.thumb, am.cli.logger)
+		if err != nil { // 缩略图缺失不影响视频本身
+			am.cli.logger.Debug("read video thumb", map[string]interface{}{"err": err, "thumb": req.thumb})
+		}
+		res.Data = data
+	}
+	if req.typ == MsgTypeImage {
+		data, err := imgutil.DecodeDatFileToBytes(req.extra, am.cli.logger)
+		if err != nil {
+			res.Err = fmt.Errorf("decrypt img error: %w", err)
+			return res
+		}
+		res.Data = data
+		if fileType, err := imgutil.DetectFileType(data); err == nil {
+			res.FileExt = string(fileType)
+		}
+	}
+	return res
+}
+
+// readThumb 读取缩略图，.dat 文件按图片解密
+func readThumb(path string, logger Logger) ([]byte, error) {
+	if strings.EqualFold(filepath.Ext(path), ".dat") {
+		return imgutil.DecodeDatFileToBytes(path, logger)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read thumb: %w", err)
+	}
+	return data, nil
+}
+
+// waitFileStable 等待文件出现且大小在连续 attachmentStableChecks 次检查中保持不变
+func waitFileStable(ctx context.Context, path string, interval time.Duration) error {
+	var last int64 = -1
+	stable := 0
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		info, err := os.Stat(path)
+		switch {
+		case err == nil && !info.IsDir():
+			if info.Size() > 0 && info.Size() == last {
+				if stable++; stable >= attachmentStableChecks {
+					return nil
+				}
+			} else {
+				stable = 0
+			}
+			last = info.Size()
+		case err != nil && !os.IsNotExist(err):
+			return fmt.Errorf("stat attachment: %w", err)
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %s: %w", ErrAttachmentTimeout, path, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// Attachments 附件下载队列
+func (c *Client) Attachments() *AttachmentManager {
+	return c.attachments
+}
@@ -0,0 +1,197 @@
+package wcf_rpc_sdk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/Clov614/wcf-rpc-sdk/internal/wcf"
+	"github.com/Clov614/wcf-rpc-sdk/internal/wcftest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newAttachmentClient(t *testing.T, opts ...Option) (*Client, *wcftest.Server) {
+	t.Helper()
+	interval, voiceInterval := attachmentPollInterval, voicePollInterval
+	attachmentPollInterval, voicePollInterval = 20*time.Millisecond, 20*time.Millisecond
+	t.Cleanup(func() { attachmentPollInterval, voicePollInterval = interval, voiceInterval })
+	srv, err := wcftest.NewServer()
+	if err != nil {
+		t.Fatalf("wcftest.NewServer() error = %v", err)
+	}
+	t.Cleanup(func() { _ = srv.Close() })
+	srv.SetUserInfo(&wcf.UserInfo{Wxid: "wxid_self"})
+	cli, err := New(context.Background(), append([]Option{WithAddr(srv.Addr())}, opts...)...)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(cli.Close)
+	return cli, srv
+}
+
+// writeChunks 模拟微信分块写入附件
+func writeChunks(path string, chunks ...[]byte) {
+	f, err := os.Create(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	for _, chunk := range chunks {
+		_, _ = f.Write(chunk)
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func recvResult(t *testing.T, ch <-chan AttachmentResult) AttachmentResult {
+	t.Helper()
+	select {
+	case res := <-ch:
+		return res
+	case <-time.After(5 * time.Second):
+		t.Fatal("等待附件下载结果超时")
+		return AttachmentResult{}
+	}
+}
+
+func TestAttachmentManager_File(t *testing.T) {
+	cli, srv := newAttachmentClient(t)
+	dir := t.TempDir()
+	var wg sync.WaitGroup
+	t.Cleanup(wg.Wait)
+	srv.Handle(wcf.Functions_FUNC_DOWNLOAD_ATTACH, func(req *wcf.Request) *wcf.Response {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			writeChunks(req.GetAtt().GetExtra(), []byte("hello "), []byte("world"))
+		}()
+		return nil
+	})
+
+	msg := &Message{MessageId: 7, Type: MsgTypeXMLFile, Extra: filepath.Join(dir, "a.txt")}
+	first := cli.Attachments().Download(context.Background(), msg)
+	second := cli.Attachments().Download(context.Background(), msg) // 下载中重复提交
+	for _, ch := range []<-chan AttachmentResult{first, second} {
+		res := recvResult(t, ch)
+		if res.Err != nil || res.Path != filepath.ToSlash(msg.Extra) || res.FileExt != ".txt" {
+			t.Fatalf("Download() = %+v", res)
+		}
+	}
+	if data, _ := os.ReadFile(msg.Extra); string(data) != "hello world" {
+		t.Errorf("downloaded file = %q, want complete content", data)
+	}
+	if n := len(srv.RequestsOf(wcf.Functions_FUNC_DOWNLOAD_ATTACH)); n != 1 {
+		t.Errorf("download requests = %d, want 1", n)
+	}
+
+	res := recvResult(t, cli.Attachments().Download(context.Background(), &Message{Type: MsgTypeText, Content: "hi"}))
+	if !errors.Is(res.Err, ErrNotAttachment) {
+		t.Errorf("Download(text) error = %v, want ErrNotAttachment", res.Err)
+	}
+}
+
+func TestAttachmentManager_Image(t *testing.T) {
+	cli, srv := newAttachmentClient(t)
+	plain := []byte{0xFF, 0xD8, 0xFF, 0xE0, 1, 2, 3}
+	dat := make([]byte, len(plain))
+	for i, b := range plain {
+		dat[i] = b ^ 0x37
+	}
+	path := filepath.Join(t.TempDir(), "img.dat")
+	srv.Handle(wcf.Functions_FUNC_DOWNLOAD_ATTACH, func(req *wcf.Request) *wcf.Response {
+		_ = os.WriteFile(req.GetAtt().GetExtra(), dat, 0o644)
+		return nil
+	})
+	res := recvResult(t, cli.Attachments().Download(context.Background(), &Message{MessageId: 8, Type: MsgTypeImage, Extra: path}))
+	if res.Err != nil || !bytes.Equal(res.Data, plain) {
+		t.Fatalf("Download(image) = %v, %v, want decoded data", res.Data, res.Err)
+	}
+}
+
+func TestAttachmentManager_Voice(t *testing.T) {
+	cli, srv := newAttachmentClient(t, WithAttachmentDownload(1, 200*time.Millisecond))
+	silk := append([]byte{0x02}, "#!SILK_V3"...)
+	srv.SetDBTables("MediaMSG0.db")
+	srv.SetQuery("MediaMSG0.db", "SELECT Buf FROM Media WHERE Reserved0 = 42;", &wcf.DbRow{Fields: []*wcf.DbField{{Type: 4, Column: "Buf", Content: silk}}})
+
+	res := recvResult(t, cli.Attachments().Download(context.Background(), &Message{MessageId: 42, Type: MsgTypeVoice}))
+	if res.Err != nil || !bytes.Equal(res.Data, silk) || res.FileExt != ".silk" {
+		t.Fatalf("Download(voice) = %+v, want silk data", res)
+	}
+	res = recvResult(t, cli.Attachments().Download(context.Background(), &Message{MessageId: 43, Type: MsgTypeVoice}))
+	if !errors.Is(res.Err, ErrAttachmentTimeout) {
+		t.Errorf("Download(voice not in db) error = %v, want ErrAttachmentTimeout", res.Err)
+	}
+}
+
+func TestAttachmentManager_Timeout(t *testing.T) {
+	cli, _ := newAttachmentClient(t, WithAttachmentDownload(1, 200*time.Millisecond))
+	msg := &Message{MessageId: 9, Type: MsgTypeVideo, Extra: filepath.Join(t.TempDir(), "never.mp4")}
+	res := recvResult(t, cli.Attachments().Download(context.Background(), msg))
+	if !errors.Is(res.Err, ErrAttachmentTimeout) {
+		t.Errorf("Download() error = %v, want ErrAttachmentTimeout", res.Err)
+	}
+	if _, err := New(context.Background(), WithAddr("tcp://127.0.0.1:1"), WithAttachmentDownload(0, time.Second)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("New() error = %v, want ErrInvalidOption", err)
+	}
+}
+
+// blockedAttachments 单个下载协程被一个永远写不完的附件占用
+func blockedAttachments(t *testing.T) (*Client, *wcftest.Server, string) {
+	t.Helper()
+	cli, srv := newAttachmentClient(t, WithAttachmentDownload(1, time.Minute))
+	started := make(chan struct{}, 1)
+	srv.Handle(wcf.Functions_FUNC_DOWNLOAD_ATTACH, func(req *wcf.Request) *wcf.Response {
+		started <- struct{}{}
+		return nil
+	})
+	dir := t.TempDir()
+	cli.Attachments().Download(context.Background(), &Message{MessageId: 1, Type: MsgTypeXMLFile, Extra: filepath.Join(dir, "1.txt")})
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("等待下载开始超时")
+	}
+	return cli, srv, dir
+}
+
+func TestAttachmentManager_CloseDrainsQueue(t *testing.T) {
+	cli, _, dir := blockedAttachments(t)
+	queued := cli.Attachments().Download(context.Background(), &Message{MessageId: 2, Type: MsgTypeXMLFile, Extra: filepath.Join(dir, "2.txt")})
+	cli.Close()
+	if res := recvResult(t, queued); !errors.Is(res.Err, context.Canceled) {
+		t.Errorf("queued Download() after Close error = %v, want context.Canceled", res.Err)
+	}
+	res := recvResult(t, cli.Attachments().Download(context.Background(), &Message{MessageId: 3, Type: MsgTypeXMLFile, Extra: filepath.Join(dir, "3.txt")}))
+	if !errors.Is(res.Err, context.Canceled) {
+		t.Errorf("Download() after Close error = %v, want context.Canceled", res.Err)
+	}
+}
+
+func TestAttachmentManager_TryDownloadQueueFull(t *testing.T) {
+	cli, _, dir := blockedAttachments(t)
+	am := cli.Attachments()
+	if !am.tryDownload(&Message{MessageId: 2, Type: MsgTypeXMLFile, Extra: filepath.Join(dir, "2.txt")}) {
+		t.Fatal("tryDownload() = false, want queued")
+	}
+	done := make(chan bool, 1)
+	go func() {
+		done <- am.tryDownload(&Message{MessageId: 3, Type: MsgTypeXMLFile, Extra: filepath.Join(dir, "3.txt")})
+	}()
+	select {
+	case ok := <-done:
+		if ok {
+			t.Errorf("tryDownload() with full queue = true, want false")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("tryDownload() blocked on full queue")
+	}
+	am.mu.Lock()
+	_, pending := am.inflight[3]
+	am.mu.Unlock()
+	if pending {
+		t.Errorf("rejected download still in flight, later Download would never run")
+	}
+}
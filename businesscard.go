@@ -0,0 +1,147 @@
+// Package wcf_rpc_sdk
+// @Author Clover
+// @Data 2026/10/17 上午2:30:00
+// @Desc 名片消息解析与发送
+package wcf_rpc_sdk
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// BusinessCard 名片消息，IsOfficial 为 true 时为公众号名片
+type BusinessCard struct {
+	Wxid         string     `json:"wxid"`
+	NickName     string     `json:"nick_name,omitempty"`
+	Alias        string     `json:"alias,omitempty"` // 微信号
+	SmallHeadURL string     `json:"small_head_url,omitempty"`
+	BigHeadURL   string     `json:"big_head_url,omitempty"`
+	Gender       GenderType `json:"gender,omitempty"`
+	Province     string     `json:"province,omitempty"`
+	City         string     `json:"city,omitempty"`
+	Sign         string     `json:"sign,omitempty"`      // 个性签名
+	CertFlag     int        `json:"cert_flag"`           // 认证标记，个人名片为 0
+	CertInfo     string     `json:"cert_info,omitempty"` // 认证信息
+	IsOfficial   bool       `json:"is_official"`
+	Ticket       string     `json:"-"` // antispamticket，添加好友时的 v4
+}
+
+// ContactInfo 名片对应的联系人信息
+func (b *BusinessCard) ContactInfo() *ContactInfo {
+	return &ContactInfo{
+		Wxid:         b.Wxid,
+		Alias:        b.Alias,
+		NickName:     b.NickName,
+		SmallHeadURL: b.SmallHeadURL,
+		BigHeadURL:   b.BigHeadURL,
+		Province:     b.Province,
+		City:         b.City,
+		Gender:       int32(b.Gender),
+	}
+}
+
+// GH 公众号名片对应的公众号，个人名片返回 nil
+func (b *BusinessCard) GH() *GH {
+	if !b.IsOfficial {
+		return nil
+	}
+	return &GH{Wxid: b.Wxid, Code: b.Alias, Name: b.NickName, Province: b.Province, City: b.City}
+}
+
+// cardXml 名片消息的 XML，收发共用
+type cardXml struct {
+	XMLName         xml.Name `xml:"msg"`
+	BigHeadImgUrl   string   `xml:"bigheadimgurl,attr"`
+	SmallHeadImgUrl string   `xml:"smallheadimgurl,attr"`
+	Username        string   `xml:"username,attr"`
+	Nickname        string   `xml:"nickname,attr"`
+	Alias           string   `xml:"alias,attr"`
+	Province        string   `xml:"province,attr"`
+	City            string   `xml:"city,attr"`
+	Sign            string   `xml:"sign,attr"`
+	Sex             string   `xml:"sex,attr"`
+	CertFlag        string   `xml:"certflag,attr"`
+	CertInfo        string   `xml:"certinfo,attr"`
+	Ticket          string   `xml:"antispamticket,attr,omitempty"`
+}
+
+// parseBusinessCard 解析名片消息
+func parseBusinessCard(content string) (*BusinessCard, error) {
+	var cx cardXml
+	if err := xml.Unmarshal([]byte(content), &cx); err != nil {
+		return nil, fmt.Errorf("xml.Unmarshal business card: %w", err)
+	}
+	if cx.Username == "" {
+		return nil, errors.New("business card without username")
+	}
+	sex, _ := strconv.Atoi(cx.Sex)
+	certFlag, _ := strconv.Atoi(cx.CertFlag)
+	return &BusinessCard{
+		Wxid:         cx.Username,
+		NickName:     cx.Nickname,
+		Alias:        cx.Alias,
+		SmallHeadURL: cx.SmallHeadImgUrl,
+		BigHeadURL:   cx.BigHeadImgUrl,
+		Gender:       GenderType(sex),
+		Province:     cx.Province,
+		City:         cx.City,
+		Sign:         cx.Sign,
+		CertFlag:     certFlag,
+		CertInfo:     cx.CertInfo,
+		IsOfficial:   strings.HasPrefix(cx.Username, "gh_") || certFlag != 0,
+		Ticket:       cx.Ticket,
+	}, nil
+}
+
+// xml 生成发送名片所用的 XML
+func (b *BusinessCard) xml() (string, error) {
+	data, err := xml.Marshal(cardXml{
+		BigHeadImgUrl:   b.BigHeadURL,
+		SmallHeadImgUrl: b.SmallHeadURL,
+		Username:        b.Wxid,
+		Nickname:        b.NickName,
+		Alias:           b.Alias,
+		Province:        b.Province,
+		City:            b.City,
+		Sign:            b.Sign,
+		Sex:             strconv.Itoa(int(b.Gender)),
+		CertFlag:        strconv.Itoa(b.CertFlag),
+		CertInfo:        b.CertInfo,
+	})
+	if err != nil {
+		return "", fmt.Errorf("xml.Marshal business card: %w", err)
+	}
+	return `<?xml version="1.0"?>` + string(data), nil
+}
+
+// SendBusinessCard 发送名片 <wxid or roomid> <名片对应的 wxid 或 gh_ 公众号 id>
+func (c *Client) SendBusinessCard(receiver, wxid string) error {
+	return c.SendBusinessCardCtx(context.Background(), receiver, wxid)
+}
+
+// SendBusinessCardCtx 同 SendBusinessCard，支持 ctx 取消与超时；名片信息通过 GetContactInfo 查询
+func (c *Client) SendBusinessCardCtx(ctx context.Context, receiver, wxid string) error {
+	info, err := c.GetContactInfo(ctx, wxid)
+	if err != nil {
+		return fmt.Errorf("card %s: %w", wxid, err)
+	}
+	card := &BusinessCard{
+		Wxid:         info.Wxid,
+		NickName:     info.NickName,
+		Alias:        info.Alias,
+		SmallHeadURL: info.SmallHeadURL,
+		BigHeadURL:   info.BigHeadURL,
+		Gender:       GenderType(info.Gender),
+		Province:     info.Province,
+		City:         info.City,
+	}
+	content, err := card.xml()
+	if err != nil {
+		return err
+	}
+	return c.sendXml(ctx, receiver, content, "", MsgTypeBusinessCard)
+}
@@ -0,0 +1,67 @@
+package wcf_rpc_sdk
+
+import (
+	"github.com/Clov614/wcf-rpc-sdk/internal/wcf"
+	"testing"
+)
+
+const (
+	personalCard = `<?xml version="1.0"?>
+<msg bigheadimgurl="http://wx.qlogo.cn/big" smallheadimgurl="http://wx.qlogo.cn/small" username="wxid_card" nickname="张三" fullpy="zhangsan" shortpy="" alias="zs123" imagestatus="3" scene="17" province="浙江" city="杭州" sign="" sex="1" certflag="0" certinfo="" brandIconUrl="" brandHomeUrl="" brandSubscriptConfigUrl="" brandFlags="0" regionCode="CN_Zhejiang_Hangzhou" antispamticket="v4_ticket" />`
+	officialCard = `<?xml version="1.0"?>
+<msg bigheadimgurl="" smallheadimgurl="http://gh/small" username="gh_123456" nickname="某公众号" alias="official" province="" city="" sign="" sex="0" certflag="24" certinfo="某公司" />`
+)
+
+func TestParseBusinessCard(t *testing.T) {
+	card, err := parseBusinessCard(personalCard)
+	if err != nil {
+		t.Fatalf("parseBusinessCard() error = %v", err)
+	}
+	if card.Wxid != "wxid_card" || card.NickName != "张三" || card.Alias != "zs123" || card.Gender != Boy || card.IsOfficial || card.Ticket != "v4_ticket" {
+		t.Errorf("parseBusinessCard() = %+v", card)
+	}
+	if info := card.ContactInfo(); info.Wxid != "wxid_card" || info.BigHeadURL != "http://wx.qlogo.cn/big" || info.City != "杭州" {
+		t.Errorf("ContactInfo() = %+v", info)
+	}
+	if card.GH() != nil {
+		t.Errorf("GH() of personal card = %+v, want nil", card.GH())
+	}
+
+	card, err = parseBusinessCard(officialCard)
+	if err != nil || !card.IsOfficial || card.CertFlag != 24 || card.CertInfo != "某公司" {
+		t.Fatalf("parseBusinessCard(official) = %+v, %v", card, err)
+	}
+	if gh := card.GH(); gh == nil || gh.Wxid != "gh_123456" || gh.Name != "某公众号" {
+		t.Errorf("GH() = %+v", gh)
+	}
+	if _, err = parseBusinessCard(`<msg nickname="x"/>`); err == nil {
+		t.Errorf("parseBusinessCard(no username) error = nil")
+	}
+}
+
+func TestClient_BusinessCard(t *testing.T) {
+	cli, srv := newOfflineClient(t)
+	if err := cli.Run(false); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	_ = srv.Push(&wcf.WxMsg{Id: 1, Type: uint32(MsgTypeBusinessCard), Sender: "wxid_friend", Content: personalCard})
+	if msg := recvMsg(t, cli); msg.Card == nil || msg.Card.Wxid != "wxid_card" {
+		t.Fatalf("Card = %+v", msg.Card)
+	}
+
+	srv.SetContacts(&wcf.RpcContact{Wxid: "wxid_friend", Code: "friend_code", Name: "friend & co", Province: "浙江", Gender: 2})
+	if err := cli.SendBusinessCard("45959390469@chatroom", "wxid_friend"); err != nil {
+		t.Fatalf("SendBusinessCard() error = %v", err)
+	}
+	reqs := srv.RequestsOf(wcf.Functions_FUNC_SEND_XML)
+	if len(reqs) != 1 || reqs[0].GetXml().GetType() != int32(MsgTypeBusinessCard) || reqs[0].GetXml().GetReceiver() != "45959390469@chatroom" {
+		t.Fatalf("send xml requests = %v", reqs)
+	}
+	sent, err := parseBusinessCard(reqs[0].GetXml().GetContent())
+	if err != nil || sent.Wxid != "wxid_friend" || sent.NickName != "friend & co" || sent.Alias != "friend_code" || sent.Gender != Girl {
+		t.Errorf("sent card = %+v, %v", sent, err)
+	}
+	if err = cli.SendBusinessCard("wxid_friend", "wxid_nobody"); err == nil {
+		t.Errorf("SendBusinessCard(unknown) error = nil")
+	}
+}
@@ -5,7 +5,6 @@
 package wcf_rpc_sdk
 
 import (
-	"github.com/Clov614/logging"
 	"sync"
 )
 
@@ -13,12 +12,14 @@ import (
 type ContactInfoManager struct {
 	contactInfoCache map[string]*ContactInfo
 	ciMu             sync.RWMutex
+	logger           Logger
 }
 
 // NewCacheInfoManager 创建缓存管理器
 func NewCacheInfoManager() *ContactInfoManager {
 	return &ContactInfoManager{
 		contactInfoCache: make(map[string]*ContactInfo),
+		logger:           defaultLogger{},
 	}
 }
 
@@ -42,5 +43,5 @@ func (cm *ContactInfoManager) GetContactInfo(id string) (*ContactInfo, bool) {
 // Close 清理缓存
 func (cm *ContactInfoManager) Close() {
 	// todo
-	logging.Warn("【wcf】close user cache")
+	cm.logger.Warn(nil, "【wcf】close user cache")
 }
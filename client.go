@@ -5,12 +5,10 @@ import (
 	"encoding/xml"
 	"errors"
 	"fmt"
-	"github.com/Clov614/logging"
 	"github.com/Clov614/wcf-rpc-sdk/internal/utils/imgutil"
+	"github.com/Clov614/wcf-rpc-sdk/internal/utils/logutil"
 	"github.com/Clov614/wcf-rpc-sdk/internal/wcf"
 	"github.com/antchfx/xmlquery"
-	"github.com/eatmoreapple/env"
-	"github.com/rs/zerolog"
 	"google.golang.org/protobuf/proto"
 	"html"
 	"os"
@@ -19,6 +17,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -30,8 +29,21 @@ const (
 var (
 	ErrNotLogin = errors.New("not login")
 	ErrNull     = errors.New("null err")
+
+	// 底层 RPC 错误，可通过 errors.Is 判断
+	ErrTransport   = wcf.ErrTransport   // RPC 服务连接异常
+	ErrTimeout     = wcf.ErrTimeout     // RPC 调用超时
+	ErrDecode      = wcf.ErrDecode      // RPC 应答无法解析
+	ErrMismatch    = wcf.ErrMismatch    // RPC 应答与请求不匹配
+	ErrUnsupported = wcf.ErrUnsupported // 当前 WCF 服务端不支持该接口
 )
 
+// ErrStatus RPC 返回失败状态码，可通过 errors.As 获取 Func 与 Code
+type ErrStatus = wcf.ErrStatus
+
+// DbRow 数据库查询结果中的一行
+type DbRow = wcf.DbRow
+
 type Client struct {
 	ctx         context.Context
 	stop        context.CancelFunc
@@ -41,92 +53,187 @@ type Client struct {
 	self        *Self
 	cacheMember *ContactInfoManager // 用户信息缓存 fixme: 更改命名
 	closeOnce   sync.Once
-	memberLock  sync.Mutex // 查询member操作互斥锁
+	memberLock  sync.Mutex       // 查询member操作互斥锁
+	logger      *logutil.Leveled // Debug 日志开关仅作用于当前客户端
+	echo        echoRegistry     // 等待自己发出消息的回显
+	moments     *momentFeed      // 朋友圈动态
+
+	contactInfoUnsupported atomic.Bool        // 服务端不支持 FUNC_GET_CONTACT_INFO，改为查询数据库
+	transferPolicies       []TransferPolicy   // 自动收款策略，全部同意时收款
+	attachments            *AttachmentManager // 附件下载队列
+
+	connNotifier       *connNotifier  // 连接状态事件
+	supervising        sync.WaitGroup // 消息接收与重连协程，Close 时等待其退出
+	reconnectBaseDelay time.Duration  // 首次重连等待时间
+	reconnectMaxDelay  time.Duration  // 重连等待时间上限
+
+	contactRefreshInterval time.Duration // 联系人缓存刷新间隔
+	selfRefreshInterval    time.Duration // 个人信息刷新间隔
 }
 
 // Close 停止客户端
 func (c *Client) Close() {
 	c.closeOnce.Do(func() {
 		c.stop()
+		c.supervising.Wait() // 等待消息端口关闭，避免关闭后仍在重连
+		c.connNotifier.close()
 		if c.cacheMember != nil {
 			c.cacheMember.Close() // 释放信息缓存
 		}
 		err := c.wxClient.Close()
 		if err != nil {
-			logging.ErrorWithErr(err, "停止wcf客户端发生了错误")
+			c.logger.Error(err, "停止wcf客户端发生了错误")
 		}
+		c.logger.Warn(nil, "wcf-sdk closed!")
 	})
-	logging.Warn("wcf-sdk closed!")
+}
+
+// New 创建客户端，ctx 结束时客户端随之停止；多个客户端可使用不同配置共存
+func New(ctx context.Context, opts ...Option) (*Client, error) {
+	if ctx == nil {
+		return nil, errors.New("ctx is nil")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	cli, err := newClient(ctx, cancel, opts...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return cli, nil
 }
 
 // NewClient <消息通道大小> <是否自动注入微信（自动打开微信）> <是否开启sdk-debug>
-func NewClient(msgChanSize int, autoInject bool, sdkDebug bool) *Client {
-	ctx, cancel := context.WithCancel(context.Background())
-	return newClient(ctx, cancel, msgChanSize, autoInject, sdkDebug)
+func NewClient(msgChanSize int, autoInject bool, sdkDebug bool) (*Client, error) {
+	return New(context.Background(), WithBufferSize(msgChanSize), WithAutoInject(autoInject), WithSDKDebug(sdkDebug))
 }
 
 // NewClientWithCtx <上下文> <退出方法> <消息通道大小> <是否自动注入微信（自动打开微信）> <是否开启sdk-debug>
-func NewClientWithCtx(ctx context.Context, cancel context.CancelFunc, msgChanSize int, autoInject bool, sdkDebug bool) *Client {
+func NewClientWithCtx(ctx context.Context, cancel context.CancelFunc, msgChanSize int, autoInject bool, sdkDebug bool) (*Client, error) {
 	if ctx == nil {
-		panic("ctx is nil")
+		return nil, errors.New("ctx is nil")
 	}
-	return newClient(ctx, cancel, msgChanSize, autoInject, sdkDebug)
+	return newClient(ctx, cancel, WithBufferSize(msgChanSize), WithAutoInject(autoInject), WithSDKDebug(sdkDebug))
 }
 
-func newClient(ctx context.Context, cancel context.CancelFunc, msgChanSize int, autoInject bool, sdkDebug bool) *Client {
-	addr := env.Name(ENVTcpAddr).StringOrElse(DefaultTcpAddr) // "tcp://127.0.0.1:10086"
-	var syncSignal = make(chan struct{})                      // 同步信号 确保注入后处理消息
-	if autoInject {                                           // 自动注入
-		port, err := strconv.Atoi(addr[strings.LastIndex(addr, ":")+1:])
-		if err != nil {
-			logging.ErrorWithErr(err, "the port is invalid, please check your address")
-			logging.Fatal("canot auto inject!", 1000, map[string]interface{}{"port": port})
+func newClient(ctx context.Context, cancel context.CancelFunc, opts ...Option) (*Client, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		if opt == nil {
+			continue
 		}
-
+		if err := opt(o); err != nil {
+			return nil, err
+		}
+	}
+	addr, err := o.resolveAddr()
+	if err != nil {
+		return nil, err
+	}
+	// Debug 日志默认关闭，Run 时按 debug 参数开启
+	logger := logutil.NewLeveled(o.logger, false)
+	if o.autoInject { // 自动注入
+		port, _ := strconv.Atoi(addr[strings.LastIndex(addr, ":")+1:]) // resolveAddr 已校验
+		var syncSignal = make(chan struct{})                           // 同步信号 确保注入后处理消息
+		var injectErr = make(chan error, 1)
 		go func() {
-			Inject(ctx, cancel, port, sdkDebug, syncSignal) // 调用sdk.dll 注入&启动微信
+			injectErr <- Inject(ctx, cancel, port, o.sdkDebug, syncSignal, logger) // 调用sdk.dll 注入&启动微信
 		}()
-		<-syncSignal
+		select {
+		case <-syncSignal:
+		case err = <-injectErr: // 注入成功前退出
+			if err == nil {
+				err = ctx.Err()
+			}
+			return nil, fmt.Errorf("inject err: %w", err)
+		}
 	}
-	close(syncSignal) // 关闭同步管道
 	wxclient, err := wcf.NewWCF(addr)
 	if err != nil {
-		logging.Fatal(fmt.Errorf("new wcf err: %w", err).Error(), 1001)
-		//panic(err)
-	}
-	return &Client{
+		return nil, fmt.Errorf("new wcf err: %w", err)
+	}
+	if o.rpcTimeout >= 0 {
+		wxclient.SetTimeout(o.rpcTimeout)
+	}
+	if err = wxclient.SetPoolConfig(o.msgPool); err != nil {
+		_ = wxclient.Close()
+		return nil, fmt.Errorf("%w: %w", ErrInvalidOption, err)
+	}
+	wxclient.SetLogger(logger)
+	msgBuffer := NewMessageBuffer(o.bufferSize) // 消息缓冲区 <缓冲大小>
+	msgBuffer.logger = logger
+	self := NewSelf(wxclient)
+	self.logger = logger
+	cacheMember := NewCacheInfoManager()
+	cacheMember.logger = logger
+	cli := &Client{
 		ctx:         ctx,
 		stop:        cancel,
-		msgBuffer:   NewMessageBuffer(msgChanSize), // 消息缓冲区 <缓冲大小>
+		msgBuffer:   msgBuffer,
 		wxClient:    wxclient,
-		self:        NewSelf(wxclient),
+		self:        self,
 		addr:        addr,
-		cacheMember: NewCacheInfoManager(),
+		cacheMember: cacheMember,
+		logger:      logger,
+		moments:     newMomentFeed(DefaultMomentBufferSize, logger),
+
+		transferPolicies: o.transferPolicies,
+
+		connNotifier:       newConnNotifier(logger),
+		reconnectBaseDelay: defaultReconnectBaseDelay,
+		reconnectMaxDelay:  defaultReconnectMaxDelay,
+
+		contactRefreshInterval: o.contactRefreshInterval,
+		selfRefreshInterval:    o.selfRefreshInterval,
 	}
+	cli.attachments = newAttachmentManager(cli, o.attachmentWorkers, o.attachmentTimeout)
+	return cli, nil
 }
 
-// Run 运行tcp监听 以及 请求tcp监听信息 <是否debug>
-func (c *Client) Run(debug bool) {
-	if debug {
-		zerolog.SetGlobalLevel(zerolog.DebugLevel)
-		logging.Debug("Debug mode enabled")
-	} else {
-		zerolog.SetGlobalLevel(zerolog.InfoLevel)
+// Run 运行tcp监听 以及 请求tcp监听信息 <是否输出 Debug 日志，仅作用于当前客户端>
+// 首次开启消息接收失败（如 RPC 服务未启动）时返回 error，之后断线由后台自动重连
+func (c *Client) Run(debug bool) error {
+	c.logger.SetDebug(debug)
+	c.logger.Debug("Debug mode enabled")
+	if err := c.handleMsg(c.ctx); err != nil { // 处理接收消息
+		return fmt.Errorf("handle msg err: %w", err)
 	}
-	// 增加项目字段
-	logging.SetField(map[string]interface{}{"sdk": "wcf-rpc-sdk"})
-	go func() { // 处理接收消息
-		err := c.handleMsg(c.ctx)
-		if err != nil {
-			logging.Fatal(fmt.Errorf("handle msg err: %w", err).Error(), 1001)
-		}
-	}()
 	go c.cyclicUpdateSelfInfo(true)  // 启动定时更新
 	go c.cyclicUpdateCacheInfo(true) // 启动定时更新
+	return nil
+}
+
+// IsLogin 是否已登录，RPC 服务异常时返回 error
+func (c *Client) IsLogin() (bool, error) {
+	return c.IsLoginCtx(context.Background())
+}
+
+// IsLoginCtx 同 IsLogin，支持 ctx 取消与超时
+func (c *Client) IsLoginCtx(ctx context.Context) (bool, error) {
+	return c.wxClient.IsLoginCtx(ctx)
+}
+
+// SetRPCTimeout 设置 RPC 调用的默认超时，调用方传入的 ctx 带有 deadline 时以 ctx 为准，<=0 时不限制
+func (c *Client) SetRPCTimeout(timeout time.Duration) {
+	c.wxClient.SetTimeout(timeout)
 }
 
-func (c *Client) IsLogin() bool {
-	return c.wxClient.IsLogin()
+// MsgPoolStats 消息处理协程池指标，可用于监控队列深度与丢弃的消息数
+func (c *Client) MsgPoolStats() MsgPoolStats {
+	return c.wxClient.PoolStats()
+}
+
+// ExecDBQuery 执行 sql 查询 <数据库名> <sql>
+func (c *Client) ExecDBQuery(db, sql string) ([]*DbRow, error) {
+	return c.ExecDBQueryCtx(context.Background(), db, sql)
+}
+
+// ExecDBQueryCtx 同 ExecDBQuery，支持 ctx 取消与超时
+func (c *Client) ExecDBQueryCtx(ctx context.Context, db, sql string) ([]*DbRow, error) {
+	rows, err := c.wxClient.ExecDBQueryCtx(ctx, db, sql)
+	if err != nil {
+		return nil, fmt.Errorf("wxClient.ExecDBQuery: %w", err)
+	}
+	return rows, nil
 }
 
 // GetMsgChan 返回消息的管道
@@ -136,6 +243,30 @@ func (c *Client) GetMsgChan() <-chan *Message {
 
 // SendText 发送普通文本 <wxid or roomid> <文本内容> <艾特的人(wxid) 所有人:(notify@all)> todo test 重构后待测试
 func (c *Client) SendText(receiver string, content string, ats ...string) error {
+	return c.SendTextCtx(context.Background(), receiver, content, ats...)
+}
+
+// SendTextCtx 同 SendText，支持 ctx 取消与超时
+func (c *Client) SendTextCtx(ctx context.Context, receiver string, content string, ats ...string) error {
+	content, atList, err := c.atText(ctx, content, ats)
+	if err != nil {
+		return err
+	}
+	return c.sendText(ctx, receiver, content, atList)
+}
+
+// sendText 发送已补全 @ 的文本
+func (c *Client) sendText(ctx context.Context, receiver string, content string, atList []string) error {
+	res, err := c.wxClient.SendTxtCtx(ctx, content, receiver, atList)
+	if err != nil {
+		c.logger.Debug("wxCliend.SendTxt", map[string]interface{}{"res": res, "receiver": receiver, "content": content, "ats": atList})
+		return fmt.Errorf("wxClient.SendTxt: %w", err)
+	}
+	return nil
+}
+
+// atText 按 @ 的成员补全消息内容，返回最终发送的内容与 @ 列表
+func (c *Client) atText(ctx context.Context, content string, ats []string) (string, []string, error) {
 	// 根据 wxid 获取对应的 Name
 	names := make([]string, 0, len(ats))
 	atList := make([]string, 0, len(ats))
@@ -145,9 +276,12 @@ func (c *Client) SendText(receiver string, content string, ats ...string) error
 			atList = append(atList, "notify@all")
 			continue
 		}
-		m := c.GetMember(wxid, true)
+		m, err := c.GetMemberCtx(ctx, wxid, true)
+		if err != nil {
+			return "", nil, err
+		}
 		if m.NickName == "" && m.Alias == "" {
-			logging.Debug("sendText NickName && Alias null", map[string]interface{}{"wxid": wxid, "info": m})
+			c.logger.Debug("sendText NickName && Alias null", map[string]interface{}{"wxid": wxid, "info": m})
 			names = append(names, wxid) // 如果获取失败，使用 wxid 代替
 			atList = append(atList, wxid)
 		} else {
@@ -173,81 +307,84 @@ func (c *Client) SendText(receiver string, content string, ats ...string) error
 			content = strings.Replace(content, "@", "@"+name+" ", 1)
 		}
 	}
-
-	// 发送文本
-	res := c.wxClient.SendTxt(content, receiver, atList)
-	if res != 0 {
-		logging.Debug("wxCliend.SendTxt", map[string]interface{}{"res": res, "receiver": receiver, "content": content, "ats": ats})
-		return fmt.Errorf("wxClient.SendTxt err, code: %d", res)
-	}
-	return nil
+	return content, atList, nil
 }
 
 // SendImage 发送图片 <wxid or roomid> <图片绝对路径>
 func (c *Client) SendImage(receiver string, src string) error {
+	return c.SendImageCtx(context.Background(), receiver, src)
+}
+
+// SendImageCtx 同 SendImage，支持 ctx 取消与超时
+func (c *Client) SendImageCtx(ctx context.Context, receiver string, src string) error {
 	var tmpFile *os.File    //  声明 tmpFile 变量
 	if imgutil.IsURL(src) { // 网络地址
 		bytes, err := imgutil.ImgFetch(src)
 		if err != nil {
-			logging.ErrorWithErr(err, "imgutil.ImgFetch")
+			c.logger.Error(err, "imgutil.ImgFetch")
 			return err
 		}
 		// 创建临时文件
 		tmpFile, err = imgutil.CreateTempFile(".jpg")
 		if err != nil {
-			logging.ErrorWithErr(err, "imgutil.CreateTempFile")
+			c.logger.Error(err, "imgutil.CreateTempFile")
 			return err
 		}
 		defer func() { // 使用闭包处理 tmpFile.Close() 的错误
 			if closeErr := tmpFile.Close(); closeErr != nil {
-				logging.ErrorWithErr(closeErr, "tmpFile.Close error in defer")
+				c.logger.Error(closeErr, "tmpFile.Close error in defer")
 			}
 		}()
 
 		// 写入临时文件
 		_, err = tmpFile.Write(bytes)
 		if err != nil {
-			logging.ErrorWithErr(err, "tmpFile.Write")
+			c.logger.Error(err, "tmpFile.Write")
 			return err
 		}
 		src = tmpFile.Name() // 使用临时文件路径
 	}
-	res := c.wxClient.SendIMG(src, receiver)
+	res, err := c.wxClient.SendIMGCtx(ctx, src, receiver)
 	if imgutil.IsURL(src) && tmpFile != nil { //  只有网络图片才删除临时文件, 并且确保 tmpFile 不为 nil
 		if removeErr := imgutil.RemoveTempFile(tmpFile.Name()); removeErr != nil {
-			logging.ErrorWithErr(removeErr, "imgutil.RemoveTempFile error")
+			c.logger.Error(removeErr, "imgutil.RemoveTempFile error")
 		}
 	}
-	if res != 0 {
-		logging.Debug("wxCliend.SendIMG", map[string]interface{}{"res": res, "receiver": receiver, "src": src}) // 打印 src 方便debug
-		return fmt.Errorf("wxClient.SendIMG err, code: %d", res)
+	if err != nil {
+		c.logger.Debug("wxCliend.SendIMG", map[string]interface{}{"res": res, "receiver": receiver, "src": src}) // 打印 src 方便debug
+		return fmt.Errorf("wxClient.SendIMG: %w", err)
 	}
 	return nil
 }
 
 // SendImageBytes 发送图片字节数据 <wxid or roomid> <图片字节>
 func (c *Client) SendImageBytes(receiver string, imgBytes []byte) error {
+	return c.SendImageBytesCtx(context.Background(), receiver, imgBytes)
+}
+
+// SendImageBytesCtx 同 SendImageBytes，支持 ctx 取消与超时
+func (c *Client) SendImageBytesCtx(ctx context.Context, receiver string, imgBytes []byte) error {
 	// 创建临时文件
 	tmpFile, err := imgutil.CreateTempFile(".jpg") // 假设图片格式为 jpg，如果需要支持其他格式，可以调整
 	if err != nil {
-		logging.ErrorWithErr(err, "imgutil.CreateTempFile for SendImageBytes")
+		c.logger.Error(err, "imgutil.CreateTempFile for SendImageBytes")
 		return err
 	}
 	defer func() {
 		// 关闭文件
 		if closeErr := tmpFile.Close(); closeErr != nil {
-			logging.ErrorWithErr(closeErr, "tmpFile.Close error in SendImageBytes defer")
+			c.logger.Error(closeErr, "tmpFile.Close error in SendImageBytes defer")
 		}
 		// 删除临时文件
 		if removeErr := imgutil.RemoveTempFile(tmpFile.Name()); removeErr != nil {
-			logging.ErrorWithErr(removeErr, "imgutil.RemoveTempFile error in SendImageBytes defer")
+			c.logger.Error(removeErr, "imgutil.RemoveTempFile error in SendImageBytes defer")
 		}
 	}()
 
 	// 写入临时文件
 	_, err = tmpFile.Write(imgBytes)
 	if err != nil {
-		logging.ErrorWithErr(err, "tmpFile.Write for SendImageBytes")
+		c.logger.Error(err, "tmpFile.Write for SendImageBytes")
 		return err
 	}
 
@@ -255,20 +392,25 @@ func (c *Client) SendImageBytes(receiver string, imgBytes []byte) error {
 	src := tmpFile.Name()
 
 	// 发送图片
-	res := c.wxClient.SendIMG(src, receiver)
-	if res != 0 {
-		logging.Debug("wxCliend.SendIMG from SendImageBytes", map[string]interface{}{"res": res, "receiver": receiver, "src_len": len(imgBytes)}) // 打印字节长度方便debug
-		return fmt.Errorf("wxClient.SendIMG from SendImageBytes err, code: %d", res)
+	res, err := c.wxClient.SendIMGCtx(ctx, src, receiver)
+	if err != nil {
+		c.logger.Debug("wxCliend.SendIMG from SendImageBytes", map[string]interface{}{"res": res, "receiver": receiver, "src_len": len(imgBytes)}) // 打印字节长度方便debug
+		return fmt.Errorf("wxClient.SendIMG from SendImageBytes: %w", err)
 	}
 	return nil
 }
 
 // SendFile 发送图片 <wxid or roomid> <文件绝对路径> todo 支持网络地址发送文件
 func (c *Client) SendFile(receiver string, src string) error {
-	res := c.wxClient.SendFile(src, receiver)
-	if res != 0 {
-		logging.Debug("wxCliend.SendFile", map[string]interface{}{"res": res, "receiver": receiver})
-		return fmt.Errorf("wxClient.SendFile err, code: %d", res)
+	return c.SendFileCtx(context.Background(), receiver, src)
+}
+
+// SendFileCtx 同 SendFile，支持 ctx 取消与超时
+func (c *Client) SendFileCtx(ctx context.Context, receiver string, src string) error {
+	res, err := c.wxClient.SendFileCtx(ctx, src, receiver)
+	if err != nil {
+		c.logger.Debug("wxCliend.SendFile", map[string]interface{}{"res": res, "receiver": receiver})
+		return fmt.Errorf("wxClient.SendFile: %w", err)
 	}
 	return nil
 }
@@ -285,50 +427,86 @@ type CardMessage struct {
 
 // SendCardMessage 发送卡片消息
 func (c *Client) SendCardMessage(receiver string, card CardMessage) error {
-	res := c.wxClient.SendRichText(card.Name, card.Account, card.Title, card.Digest, card.URL, card.ThumbURL, receiver)
-	if res != 1 {
-		logging.Debug("wxClient.SendRichText", map[string]interface{}{"res": res, "receiver": receiver, "card": card})
-		return fmt.Errorf("wxClient.SendRichText err, code: %d", res)
+	return c.SendCardMessageCtx(context.Background(), receiver, card)
+}
+
+// SendCardMessageCtx 同 SendCardMessage，支持 ctx 取消与超时
+func (c *Client) SendCardMessageCtx(ctx context.Context, receiver string, card CardMessage) error {
+	res, err := c.wxClient.SendRichTextCtx(ctx, card.Name, card.Account, card.Title, card.Digest, card.URL, card.ThumbURL, receiver)
+	if err != nil {
+		c.logger.Debug("wxClient.SendRichText", map[string]interface{}{"res": res, "receiver": receiver, "card": card})
+		return fmt.Errorf("wxClient.SendRichText: %w", err)
 	}
 	return nil
 }
 
 // AcceptNewFriend 通过好友请求
-func (c *Client) AcceptNewFriend(req NewFriendReq) bool {
-	return 1 == c.wxClient.AcceptFriend(req.V3, req.V4, req.Scene) // 1 为成功
+func (c *Client) AcceptNewFriend(req NewFriendReq) error {
+	return c.AcceptNewFriendCtx(context.Background(), req)
+}
+
+// AcceptNewFriendCtx 同 AcceptNewFriend，支持 ctx 取消与超时
+func (c *Client) AcceptNewFriendCtx(ctx context.Context, req NewFriendReq) error {
+	if _, err := c.wxClient.AcceptFriendCtx(ctx, req.V3, req.V4, req.Scene); err != nil {
+		return fmt.Errorf("wxClient.AcceptFriend: %w", err)
+	}
+	return nil
 }
 
 // CtFriends 获取通讯录所有好友
 func (c *Client) CtFriends() ([]Friend, error) {
-	fs, b := c.self.CtFriends()
-	if !b {
-		return nil, fmt.Errorf("self.CtFriends err")
+	return c.CtFriendsCtx(context.Background())
+}
+
+// CtFriendsCtx 同 CtFriends，支持 ctx 取消与超时
+func (c *Client) CtFriendsCtx(ctx context.Context) ([]Friend, error) {
+	fs, err := c.self.CtFriendsCtx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("self.CtFriends: %w", err)
 	}
 	return fs, nil
 }
 
 // CtChatRooms 获取通讯录所有群聊
 func (c *Client) CtChatRooms() ([]ChatRoom, error) {
-	cr, b := c.self.ChatRooms()
-	if !b {
-		return nil, fmt.Errorf("self.ChatRooms err")
+	return c.CtChatRoomsCtx(context.Background())
+}
+
+// CtChatRoomsCtx 同 CtChatRooms，支持 ctx 取消与超时
+func (c *Client) CtChatRoomsCtx(ctx context.Context) ([]ChatRoom, error) {
+	cr, err := c.self.ChatRoomsCtx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("self.ChatRooms: %w", err)
 	}
 	return cr, nil
 }
 
 // CtGHs 获取通讯录所有公众号
 func (c *Client) CtGHs() ([]GH, error) {
-	ghs, b := c.self.CtGHs()
-	if !b {
-		return nil, fmt.Errorf("self.CtGHs err")
+	return c.CtGHsCtx(context.Background())
+}
+
+// CtGHsCtx 同 CtGHs，支持 ctx 取消与超时
+func (c *Client) CtGHsCtx(ctx context.Context) ([]GH, error) {
+	ghs, err := c.self.CtGHsCtx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("self.CtGHs: %w", err)
 	}
 	return ghs, nil
 }
 
 // RoomMembers 获取群成员信息
 func (c *Client) RoomMembers(roomId string) ([]*ContactInfo, error) {
-	contacts := c.wxClient.ExecDBQuery("MicroMsg.db", "SELECT RoomData FROM ChatRoom WHERE ChatRoomName = '"+roomId+"';")
-	logging.Debug("GetRoomMemberID", map[string]interface{}{"roomId": roomId, "contacts": contacts})
+	return c.RoomMembersCtx(context.Background(), roomId)
+}
+
+// RoomMembersCtx 同 RoomMembers，支持 ctx 取消与超时
+func (c *Client) RoomMembersCtx(ctx context.Context, roomId string) ([]*ContactInfo, error) {
+	contacts, err := c.wxClient.ExecDBQueryCtx(ctx, "MicroMsg.db", "SELECT RoomData FROM ChatRoom WHERE ChatRoomName = '"+roomId+"';")
+	if err != nil {
+		return nil, fmt.Errorf("query room data: %w", err)
+	}
+	c.logger.Debug("GetRoomMemberID", map[string]interface{}{"roomId": roomId, "contacts": contacts})
 
 	if len(contacts) == 0 || len(contacts[0].GetFields()) == 0 {
 		return nil, fmt.Errorf("no room data found for roomId: %s", roomId)
@@ -338,13 +516,16 @@ func (c *Client) RoomMembers(roomId string) ([]*ContactInfo, error) {
 
 	roomData := &wcf.RoomData{}
 
-	err := proto.Unmarshal(roomDataBytes, roomData)
+	err = proto.Unmarshal(roomDataBytes, roomData)
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal RoomData: %w", err)
 	}
 	var roomMembers = make([]*ContactInfo, len(roomData.GetMembers()))
 	for i, member := range roomData.GetMembers() {
-		roomMembers[i] = c.GetMember(member.Wxid, true)
+		roomMembers[i], err = c.GetMemberCtx(ctx, member.Wxid, true)
+		if err != nil {
+			return nil, err
+		}
 		roomMembers[i].Wxid = member.Wxid
 		roomMembers[i].Alias = member.Name
 	}
@@ -352,20 +533,28 @@ func (c *Client) RoomMembers(roomId string) ([]*ContactInfo, error) {
 	return roomMembers, nil
 }
 
-// ChatRoomOwner 获取群主
-func (c *Client) ChatRoomOwner(roomId string) *ContactInfo {
-	res := c.wxClient.ExecDBQuery("MicroMsg.db", "SELECT Reserved2 FROM ChatRoom WHERE ChatRoomName = '"+roomId+"';")
-	if res == nil || len(res) == 0 || len(res[0].GetFields()) == 0 {
-		logging.Debug("获取群组错误", map[string]interface{}{"roomId": roomId, "res": res})
-		return nil
+// ChatRoomOwner 获取群主，未缓存群主信息时返回 nil
+func (c *Client) ChatRoomOwner(roomId string) (*ContactInfo, error) {
+	return c.ChatRoomOwnerCtx(context.Background(), roomId)
+}
+
+// ChatRoomOwnerCtx 同 ChatRoomOwner，支持 ctx 取消与超时
+func (c *Client) ChatRoomOwnerCtx(ctx context.Context, roomId string) (*ContactInfo, error) {
+	res, err := c.wxClient.ExecDBQueryCtx(ctx, "MicroMsg.db", "SELECT Reserved2 FROM ChatRoom WHERE ChatRoomName = '"+roomId+"';")
+	if err != nil {
+		return nil, fmt.Errorf("query room owner: %w", err)
+	}
+	if len(res) == 0 || len(res[0].GetFields()) == 0 {
+		c.logger.Debug("获取群组错误", map[string]interface{}{"roomId": roomId, "res": res})
+		return nil, nil
 	}
 	Reserved2 := res[0].GetFields()[0].Content
 	wxid := string(Reserved2)
 	info, ok := c.cacheMember.GetContactInfo(wxid)
 	if ok || info != nil {
-		return info // 返回群主信息
+		return info, nil // 返回群主信息
 	}
-	return nil
+	return nil, nil
 }
 
 // GetSelfInfo 获取账号个人信息
@@ -391,19 +580,33 @@ func (c *Client) GetSelfFileStoragePath() (string, bool) {
 	return info.FileStoragePath, ok
 }
 
-func (c *Client) GetMember(id string, byCache bool) *ContactInfo {
+// GetMember 获取联系人信息 <wxid> <是否优先走缓存>
+func (c *Client) GetMember(id string, byCache bool) (*ContactInfo, error) {
+	return c.GetMemberCtx(context.Background(), id, byCache)
+}
+
+// GetMemberCtx 同 GetMember，支持 ctx 取消与超时；未走缓存时优先使用 FUNC_GET_CONTACT_INFO，服务端不支持时查询数据库
+func (c *Client) GetMemberCtx(ctx context.Context, id string, byCache bool) (*ContactInfo, error) {
 	if byCache { // 走缓存
 		info, b := c.cacheMember.GetContactInfo(id)
 		if b {
-			return info
+			return info, nil
 		}
 	}
+	return c.queryContact(ctx, id)
+}
+
+// queryContactDB 从 Contact 表查询联系人，不存在时返回空的 ContactInfo
+func (c *Client) queryContactDB(ctx context.Context, id string) (*ContactInfo, error) {
 	var cInfo = &ContactInfo{}
-	contacts := c.wxClient.ExecDBQuery("MicroMsg.db", fmt.Sprintf("select * from Contact where UserName = '%s';", id)) // 注意 原字段 UserName指的就是 wxid
+	contacts, err := c.wxClient.ExecDBQueryCtx(ctx, "MicroMsg.db", fmt.Sprintf("select * from Contact where UserName = '%s';", id)) // 注意 原字段 UserName指的就是 wxid
+	if err != nil {
+		return nil, fmt.Errorf("query contact: %w", err)
+	}
 	if len(contacts) != 0 {
 		c.nomalize(contacts[0], cInfo)
 	}
-	return cInfo
+	return cInfo, nil
 }
 
 // cyclicUpdateSelfInfo 定时更新机器人信息 <immediate 立即执行一次>
@@ -411,14 +614,14 @@ func (c *Client) cyclicUpdateSelfInfo(immediate bool) {
 	if immediate {
 		c.self.UpdateInfo()
 	}
-	ticker := time.NewTicker(time.Hour * 2)
+	ticker := time.NewTicker(c.selfRefreshInterval)
 	defer ticker.Stop()
 	for {
 		select {
 		case <-c.ctx.Done():
 			return
 		case <-ticker.C:
-			c.self.UpdateInfo() // 每30 分钟更新一次
+			c.self.UpdateInfo() // 定时更新个人信息与通讯录
 			c.self.UpdateContact()
 		}
 	}
@@ -453,7 +656,7 @@ func (c *Client) cyclicUpdateCacheInfo(immediate bool) {
 	if immediate {
 		c.updateCacheInfo(false)
 	}
-	ticker := time.NewTicker(time.Minute * 30)
+	ticker := time.NewTicker(c.contactRefreshInterval)
 	defer ticker.Stop()
 	for {
 		select {
@@ -467,8 +670,13 @@ func (c *Client) cyclicUpdateCacheInfo(immediate bool) {
 
 // 更新缓存用户信息 <isAsync GetAllMember是否异步>
 func (c *Client) updateCacheInfo(isAsync bool) {
-	if !c.wxClient.IsLogin() { // fixme: 登入后运行时扔可能获取到登录错误
-		logging.WarnWithErr(ErrNotLogin, "[尚未登陆]跳过更新联系人信息")
+	isLogin, err := c.wxClient.IsLoginCtx(c.ctx)
+	if err != nil {
+		c.logger.Warn(err, "查询登录状态失败，跳过更新联系人信息")
+		return
+	}
+	if !isLogin { // fixme: 登入后运行时扔可能获取到登录错误
+		c.logger.Warn(ErrNotLogin, "[尚未登陆]跳过更新联系人信息")
 		return
 	}
 	if isAsync {
@@ -484,9 +692,13 @@ func (c *Client) getAllMember() *[]*ContactInfo {
 		return nil
 	}
 	defer c.memberLock.Unlock()
-	contacts := c.wxClient.ExecDBQuery("MicroMsg.db", "select * from Contact;")
+	contacts, err := c.wxClient.ExecDBQueryCtx(c.ctx, "MicroMsg.db", "select * from Contact;")
+	if err != nil {
+		c.logger.Error(err, "client.getAllMember: queryDB err")
+		return nil
+	}
 	if len(contacts) == 0 {
-		logging.Error("client.getAllMember: queryDB res is nil")
+		c.logger.Error(nil, "client.getAllMember: queryDB res is nil")
 		return nil
 	}
 	var memberList = make([]*ContactInfo, 0, len(contacts))
@@ -495,7 +707,7 @@ func (c *Client) getAllMember() *[]*ContactInfo {
 		c.nomalize(contact, cInfo)
 		memberList = append(memberList, cInfo)
 	}
-	//logging.Debug("client.getAllMember()", map[string]interface{}{"memberList": memberList})
+	//c.logger.Debug("client.getAllMember()", map[string]interface{}{"memberList": memberList})
 	return &memberList
 }
 
@@ -511,7 +723,7 @@ func (c *Client) nomalize(contact *wcf.DbRow, cInfo *ContactInfo) {
 			if num, err := strconv.ParseUint(string(field.Content), 10, 8); err == nil {
 				cInfo.DelFlag = uint8(num)
 			} else {
-				logging.WarnWithErr(err, "error parsing DelFlag")
+				c.logger.Warn(err, "error parsing DelFlag")
 				cInfo.DelFlag = 0 // todo 或者其他默认值
 			}
 		case "Type":
@@ -538,28 +750,36 @@ func (c *Client) nomalize(contact *wcf.DbRow, cInfo *ContactInfo) {
 			cInfo.BigHeadURL = string(field.Content)
 		}
 	}
-	// 查询小头像和大头像
-	if cInfo.Wxid != "" {
-		query := c.wxClient.ExecDBQuery("MicroMsg.db", fmt.Sprintf("select * from ContactHeadImgUrl where usrName = '%s';", cInfo.Wxid))
-		for _, row := range query {
-			for _, field := range row.Fields {
-				switch field.Column {
-				case "smallHeadImgUrl":
-					cInfo.SmallHeadURL = string(field.Content)
-				case "bigHeadImgUrl":
-					cInfo.BigHeadURL = string(field.Content)
-				}
+	// Contact 表中没有头像时查询 ContactHeadImgUrl
+	if cInfo.Wxid != "" && cInfo.SmallHeadURL == "" && cInfo.BigHeadURL == "" {
+		c.queryHeadImg(c.ctx, cInfo)
+	}
+	c.cacheMember.CacheContactInfo(cInfo) // 更新缓存
+}
+
+// queryHeadImg 从 ContactHeadImgUrl 表补全头像
+func (c *Client) queryHeadImg(ctx context.Context, cInfo *ContactInfo) {
+	query, err := c.wxClient.ExecDBQueryCtx(ctx, "MicroMsg.db", fmt.Sprintf("select * from ContactHeadImgUrl where usrName = '%s';", cInfo.Wxid))
+	if err != nil {
+		c.logger.Debug("query ContactHeadImgUrl err", map[string]interface{}{"wxid": cInfo.Wxid, "err": err.Error()})
+	}
+	for _, row := range query {
+		for _, field := range row.Fields {
+			switch field.Column {
+			case "smallHeadImgUrl":
+				cInfo.SmallHeadURL = string(field.Content)
+			case "bigHeadImgUrl":
+				cInfo.BigHeadURL = string(field.Content)
 			}
 		}
 	}
-	c.cacheMember.CacheContactInfo(cInfo) // 更新缓存
 }
 
 // GetFullFilePathFromRelativePath 通过相对路径获取完整文件路径
 func (c *Client) GetFullFilePathFromRelativePath(relativePath string) string {
 	fileStoragePath, ok := c.GetSelfFileStoragePath()
 	if fileStoragePath == "" || !ok {
-		logging.Error("GetFullFilePathFromRelativePath: FileStoragePath is empty")
+		c.logger.Error(nil, "GetFullFilePathFromRelativePath: FileStoragePath is empty")
 		return "" // 或者返回错误
 	}
 	// todo 后续可能支持其他的dat解析
@@ -570,9 +790,9 @@ func (c *Client) GetFullFilePathFromRelativePath(relativePath string) string {
 
 // DecodeDatFileToBytes 解码 .dat 文件为图片, 并返回字节数组
 func (c *Client) DecodeDatFileToBytes(datPath string) []byte {
-	bytes, err := imgutil.DecodeDatFileToBytes(datPath)
+	bytes, err := imgutil.DecodeDatFileToBytes(datPath, c.logger)
 	if err != nil {
-		logging.ErrorWithErr(err, "DecodeDatFileToBytes", nil)
+		c.logger.Error(err, "DecodeDatFileToBytes", nil)
 		return nil
 	}
 	return bytes
@@ -580,37 +800,41 @@ func (c *Client) DecodeDatFileToBytes(datPath string) []byte {
 
 func (c *Client) handleMsg(ctx context.Context) (err error) {
 	var handler wcf.MsgHandler = func(msg *wcf.WxMsg) error { // 回调函数
+		if msg != nil {
+			c.echo.dispatch(msg) // 先于转换，covertMsg 会清空私聊的 Roomid
+		}
+		if msg != nil && MsgType(msg.Type) == MsgTypeMoments { // 朋友圈动态走单独的通道
+			return c.moments.put(msg)
+		}
 		covertedMsg := c.covertMsg(msg)
 		if covertedMsg == nil {
 			return ErrNull
 		}
-		err = c.msgBuffer.Put(c.ctx, covertedMsg) // 缓冲消息（内存中）
+		err := c.msgBuffer.Put(c.ctx, covertedMsg) // 缓冲消息（内存中）
 		if err != nil {
 			return fmt.Errorf("MessageHandler err: %w", err)
 		}
 		return nil
 	}
+	started := make(chan error, 1)
+	c.supervising.Add(1)
 	go func() {
-		//c.wxClient.DisableRecvTxt()          // 重置可能的状态
-		c.wxClient.EnableRecvTxt()           // 允许接收消息
-		err = c.wxClient.OnMSG(ctx, handler) // 当消息到来时，处理消息
-		if err != nil {
-			logging.ErrorWithErr(err, "handlerMsg err")
-		}
+		defer c.supervising.Done()
+		c.supervise(ctx, handler, started) // 断线时自动重连
 	}()
-	return nil
+	return <-started
 }
 
 func (c *Client) covertMsg(msg *wcf.WxMsg) *Message {
 	if msg == nil {
-		logging.ErrorWithErr(ErrNull, "internal msg is nil")
+		c.logger.Error(ErrNull, "internal msg is nil")
 		return nil
 	}
 	var roomMembers []*ContactInfo
 	if msg.IsGroup { // 群聊消息
 		member, err := c.RoomMembers(msg.Roomid)
 		if err != nil {
-			logging.Debug("get room member err", map[string]interface{}{"err": err.Error()})
+			c.logger.Debug("get room member err", map[string]interface{}{"err": err.Error()})
 		}
 		roomMembers = member
 	} else { // 不是群组消息
@@ -619,7 +843,7 @@ func (c *Client) covertMsg(msg *wcf.WxMsg) *Message {
 	rd := &RoomData{Members: roomMembers}
 	id, b := c.GetSelfWxId()
 	if b {
-		rd.AnalyseMemberAt(id, msg.Content)
+		rd.analyseMemberAt(id, msg.Content, c.logger)
 	}
 	m := &Message{
 		IsSelf:    msg.IsSelf,
@@ -639,14 +863,69 @@ func (c *Client) covertMsg(msg *wcf.WxMsg) *Message {
 	}
 	// 好友申请解析
 	if m.Type == MsgTypeFriendConfirm {
-		fillNewFriendReq(m)
+		fillNewFriendReq(m, c.logger)
+	}
+
+	// 位置解析
+	if m.Type == MsgTypeLocation {
+		loc, err := parseLocation(msg.Content)
+		if err != nil {
+			c.logger.Debug("parseLocation", map[string]interface{}{"err": err, "content": msg.Content})
+		} else {
+			m.Location = loc
+		}
+	}
+
+	// 名片解析
+	if m.Type == MsgTypeBusinessCard {
+		card, err := parseBusinessCard(msg.Content)
+		if err != nil {
+			c.logger.Debug("parseBusinessCard", map[string]interface{}{"err": err, "content": msg.Content})
+		} else {
+			m.Card = card
+		}
+	}
+
+	// 拍一拍解析
+	if m.Type == MsgTypeRevoke || m.Type == MsgTypePat {
+		pat, err := parsePatEvent(msg.Content)
+		if err != nil {
+			c.logger.Debug("parsePatEvent", map[string]interface{}{"err": err, "content": msg.Content})
+		} else if pat != nil {
+			m.Type = MsgTypePat
+			m.Pat = pat
+			if m.RoomId == "" {
+				m.RoomId = pat.RoomId
+			}
+		}
+	}
+
+	// 群系统消息解析
+	if m.Type == MsgTypeSystem || m.Type == MsgTypeRevoke {
+		m.GroupEvent = c.parseGroupEvent(m)
+	}
+
+	// 红包解析，appmsg 中的红包在解析 XML 时处理
+	if m.Type != MsgTypeXML {
+		c.parseRedPacket(m)
+	}
+
+	// 视频解析，视频文件需通过 Attachments().Download 下载
+	if m.Type == MsgTypeVideo || m.Type == MsgTypeShortVideo {
+		video, err := parseVideoMsg(msg.Content, msg.Thumb, msg.Extra)
+		if err != nil {
+			c.logger.Debug("parseVideoMsg", map[string]interface{}{"err": err, "content": msg.Content})
+		} else {
+			m.Video = video
+		}
 	}
 
 	// 图片数据解析
 	if m.Type == MsgTypeImage {
-		time.Sleep(50 * time.Microsecond)
-		c.wxClient.DownloadAttach(m.MessageId, m.Thumb, m.Extra) // 下载图片
-		m.FileInfo = &FileInfo{FilePath: filepath.ToSlash(m.Extra), IsImg: true}
+		if !c.attachments.tryDownload(m) { // 异步下载图片，队列已满时不阻塞收消息，调用 Attachments().Download 时再下载
+			c.logger.Debug("attachment queue full, download deferred", map[string]interface{}{"messageId": m.MessageId})
+		}
+		m.FileInfo = &FileInfo{FilePath: filepath.ToSlash(m.Extra), IsImg: true, logger: c.logger}
 	}
 
 	// 解析XML
@@ -654,26 +933,44 @@ func (c *Client) covertMsg(msg *wcf.WxMsg) *Message {
 		if strings.Contains(msg.Content, "<refermsg>") {
 			referMsg, content, err := parseReferMsg(msg.Content)
 			if err != nil {
-				logging.Debug("parseReferMsg", map[string]interface{}{"err": err, "xml": msg.Xml})
+				c.logger.Debug("parseReferMsg", map[string]interface{}{"err": err, "xml": msg.Xml})
 			} else {
 				m.Type = MsgTypeXMLQuote
 				m.Quote = &referMsg.Quote
 				m.Content = content
 			}
+		} else if strings.Contains(msg.Content, "<wcpayinfo>") { // 转账
+			transfer, err := parseTransferMsg(msg.Content)
+			if err != nil {
+				c.logger.Debug("parseTransferMsg", map[string]interface{}{"err": err, "xml": msg.Xml})
+			} else if transfer != nil {
+				m.Type = MsgTypeXMLTransfer
+				m.Transfer = transfer
+			} else { // 红包
+				c.parseRedPacket(m)
+			}
 		} else if strings.Contains(msg.Content, "<recorditem>") { // 新增的转发消息解析逻辑
 			forwardMsg, err := parseForwardMsg(msg.Content)
 			if err != nil {
-				logging.Debug("parseForwardMsg", map[string]interface{}{"err": err, "xml": msg.Xml})
+				c.logger.Debug("parseForwardMsg", map[string]interface{}{"err": err, "xml": msg.Xml})
 			} else {
 				m.Type = MsgTypeXMLForward // 假设您已经定义了这个新的消息类型
 				m.Forward = forwardMsg
 			}
+		} else if mp, err := parseMiniProgramMsg(msg.Content); err == nil && mp != nil { // 小程序
+			m.Type = MsgTypeXMLMiniProgram
+			m.MiniProgram = mp
+			m.Content = mp.Title
+		} else if link, err := parseLinkMsg(msg.Content); err == nil && link != nil { // 链接
+			m.Type = MsgTypeXMLLink
+			m.Link = link
+			m.Content = link.Title
 		} else {
 			// 检查是否是文件类型
 			fileMsg := &FileMsg{}
 			err := xml.Unmarshal([]byte(msg.Content), fileMsg)
 			if err != nil {
-				logging.Debug("xml.Unmarshal fileMsg", map[string]interface{}{"err": err, "xml": msg.Xml})
+				c.logger.Debug("xml.Unmarshal fileMsg", map[string]interface{}{"err": err, "xml": msg.Xml})
 			} else {
 				if fileMsg.FileExt != "" {
 					m.Type = MsgTypeXMLFile
@@ -694,14 +991,17 @@ func (c *Client) covertMsg(msg *wcf.WxMsg) *Message {
 		self:   c.self,
 	}
 	m.meta = metaData
+	if m.Transfer != nil {
+		c.autoAcceptTransfer(m)
+	}
 	return m
 }
 
-func fillNewFriendReq(m *Message) {
+func fillNewFriendReq(m *Message, logger Logger) {
 	if m.Content != "" { // 确保 Content 不为空
 		doc, err := xmlquery.Parse(strings.NewReader(m.Content))
 		if err != nil {
-			logging.ErrorWithErr(err, "Failed to parse friend request XML", map[string]interface{}{"messageId": m.MessageId, "content": m.Content})
+			logger.Error(err, "Failed to parse friend request XML", map[string]interface{}{"messageId": m.MessageId, "content": m.Content})
 		} else {
 			msgNode := xmlquery.FindOne(doc, "/msg") // 查找根节点 <msg>
 			if msgNode != nil {
@@ -713,13 +1013,13 @@ func fillNewFriendReq(m *Message) {
 				if sceneStr != "" {
 					sceneVal, err = strconv.ParseInt(sceneStr, 10, 64) // 解析 scene 为 int
 					if err != nil {
-						logging.ErrorWithErr(err, "Failed to parse scene attribute in friend request", map[string]interface{}{"messageId": m.MessageId, "sceneStr": sceneStr})
+						logger.Error(err, "Failed to parse scene attribute in friend request", map[string]interface{}{"messageId": m.MessageId, "sceneStr": sceneStr})
 						// 解析失败，可以设置默认值或保持为0
 						sceneVal = 0
 					}
 				} else {
 					// scene 属性可能不存在或为空
-					logging.Warn("Scene attribute missing or empty in friend request", map[string]interface{}{"messageId": m.MessageId})
+					logger.Warn(nil, "Scene attribute missing or empty in friend request", map[string]interface{}{"messageId": m.MessageId})
 					sceneVal = 0 // 默认值
 				}
 
@@ -729,13 +1029,13 @@ func fillNewFriendReq(m *Message) {
 					V4:    v4,
 					Scene: sceneVal, // 转换为 int32
 				}
-				logging.Debug("Parsed friend request", map[string]interface{}{"v3": v3, "v4_len": len(v4), "scene": m.NewFriendReq.Scene}) // 打印 V4 长度避免日志过长
+				logger.Debug("Parsed friend request", map[string]interface{}{"v3": v3, "v4_len": len(v4), "scene": m.NewFriendReq.Scene}) // 打印 V4 长度避免日志过长
 			} else {
-				logging.Error("Could not find <msg> node in friend request XML", map[string]interface{}{"messageId": m.MessageId, "content": m.Content})
+				logger.Error(nil, "Could not find <msg> node in friend request XML", map[string]interface{}{"messageId": m.MessageId, "content": m.Content})
 			}
 		}
 	} else {
-		logging.Warn("Friend request message content is empty", map[string]interface{}{"messageId": m.MessageId})
+		logger.Warn(nil, "Friend request message content is empty", map[string]interface{}{"messageId": m.MessageId})
 	}
 }
 
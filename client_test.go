@@ -1,18 +1,45 @@
 package wcf_rpc_sdk
 
 import (
+	"context"
+	"errors"
+	"github.com/Clov614/wcf-rpc-sdk/internal/wcf"
+	"github.com/Clov614/wcf-rpc-sdk/internal/wcftest"
+	"google.golang.org/protobuf/proto"
+	"os"
 	"path/filepath"
 	"testing"
 	"time"
 )
 
+// requireLive 需要真实微信的测试，未设置 TCP_ADDR 时跳过（离线测试见 newOfflineClient）
+func requireLive(t *testing.T) {
+	t.Helper()
+	if os.Getenv(ENVTcpAddr) == "" {
+		t.Skip("TCP_ADDR 未设置，跳过需要真实微信的测试")
+	}
+}
+
+// mustNewClient 创建客户端，失败时终止测试
+func mustNewClient(t *testing.T, msgChanSize int, autoInject bool, sdkDebug bool) *Client {
+	t.Helper()
+	cli, err := NewClient(msgChanSize, autoInject, sdkDebug)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	return cli
+}
+
 // TestClient_Recv 持续接收消息
 func TestClient_Recv(t *testing.T) {
+	requireLive(t)
 	// 创建客户端实例
-	cli := NewClient(10, false, false)
+	cli := mustNewClient(t, 10, false, false)
 
 	// 启动客户端，这里假设不需要自动注入微信
-	cli.Run(false)
+	if err := cli.Run(false); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
 	// 关闭客户端
 	defer cli.Close()
 	for msg := range cli.GetMsgChan() {
@@ -21,11 +48,14 @@ func TestClient_Recv(t *testing.T) {
 }
 
 func TestClient_SendTextAndGetMsg(t *testing.T) {
+	requireLive(t)
 	// 创建客户端实例
-	client := NewClient(10, false, false)
+	client := mustNewClient(t, 10, false, false)
 
 	// 启动客户端，这里假设不需要自动注入微信
-	client.Run(false)
+	if err := client.Run(false); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
 	// 关闭客户端
 	defer client.Close()
 	// 等待客户端连接
@@ -51,11 +81,14 @@ func TestClient_SendTextAndGetMsg(t *testing.T) {
 }
 
 func TestClient_SendGroupTextAndAt(t *testing.T) {
+	requireLive(t)
 	// 创建客户端实例
-	client := NewClient(10, false, false)
+	client := mustNewClient(t, 10, false, false)
 
 	// 启动客户端
-	client.Run(false)
+	if err := client.Run(false); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
 	defer client.Close()
 
 	// 测试 SendText At
@@ -82,15 +115,21 @@ func TestClient_SendGroupTextAndAt(t *testing.T) {
 }
 
 func TestClient_GetContacts(t *testing.T) {
+	requireLive(t)
 	// 创建客户端实例
-	client := NewClient(10, false, false)
+	client := mustNewClient(t, 10, false, false)
 	defer client.Close()
 
 	// 启动客户端
-	client.Run(false)
+	if err := client.Run(false); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
 
 	// 测试 GetContacts
-	contacts := client.wxClient.GetContacts()
+	contacts, err := client.wxClient.GetContacts()
+	if err != nil {
+		t.Fatalf("获取联系人列表失败: %v", err)
+	}
 	if len(contacts) == 0 {
 		t.Fatalf("获取联系人列表失败: 列表空")
 	}
@@ -103,11 +142,14 @@ func TestClient_GetContacts(t *testing.T) {
 }
 
 func TestClient_GetRoomMembers(t *testing.T) {
+	requireLive(t)
 	// 创建客户端实例
-	client := NewClient(10, false, false)
+	client := mustNewClient(t, 10, false, false)
 
 	// 启动客户端
-	client.Run(true)
+	if err := client.Run(true); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
 	defer client.Close()
 
 	roomId := "45959390469@chatroom"
@@ -121,11 +163,14 @@ func TestClient_GetRoomMembers(t *testing.T) {
 }
 
 func TestClient_CtFriends(t *testing.T) {
+	requireLive(t)
 	// 创建客户端实例
-	client := NewClient(10, false, false)
+	client := mustNewClient(t, 10, false, false)
 
 	// 启动客户端
-	client.Run(true)
+	if err := client.Run(true); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
 	defer client.Close()
 
 	friends, err := client.CtFriends()
@@ -138,11 +183,14 @@ func TestClient_CtFriends(t *testing.T) {
 }
 
 func TestClient_CtChatRooms(t *testing.T) {
+	requireLive(t)
 	// 创建客户端实例
-	client := NewClient(10, false, false)
+	client := mustNewClient(t, 10, false, false)
 
 	// 启动客户端
-	client.Run(true)
+	if err := client.Run(true); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
 	defer client.Close()
 
 	crs, err := client.CtChatRooms()
@@ -155,11 +203,14 @@ func TestClient_CtChatRooms(t *testing.T) {
 }
 
 func TestClient_CtGHs(t *testing.T) {
+	requireLive(t)
 	// 创建客户端实例
-	client := NewClient(10, false, false)
+	client := mustNewClient(t, 10, false, false)
 
 	// 启动客户端
-	client.Run(true)
+	if err := client.Run(true); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
 	defer client.Close()
 
 	ghs, err := client.CtGHs()
@@ -172,29 +223,44 @@ func TestClient_CtGHs(t *testing.T) {
 }
 
 func TestClient_QueryRoomTable(t *testing.T) {
-	c := NewClient(10, false, false)
+	requireLive(t)
+	c := mustNewClient(t, 10, false, false)
 	defer c.Close()
-	c.Run(true)
+	if err := c.Run(true); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
 	roomId := "45959390469@chatroom"
-	contacts := c.wxClient.ExecDBQuery("MicroMsg.db", "SELECT * FROM ChatRoom WHERE ChatRoomName = '"+roomId+"';")
+	contacts, err := c.wxClient.ExecDBQuery("MicroMsg.db", "SELECT * FROM ChatRoom WHERE ChatRoomName = '"+roomId+"';")
+	if err != nil {
+		t.Fatal(err)
+	}
 	t.Log(contacts)
 }
 
 func TestClient_ChatRoomOwner(t *testing.T) {
-	c := NewClient(10, false, false)
+	requireLive(t)
+	c := mustNewClient(t, 10, false, false)
 	defer c.Close()
-	c.Run(true)
+	if err := c.Run(true); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
 	roomId := "45959390469@chatroom"
-	owner := c.ChatRoomOwner(roomId)
+	owner, err := c.ChatRoomOwner(roomId)
+	if err != nil {
+		t.Fatal(err)
+	}
 	t.Logf("%#v", owner)
 }
 
 func TestClient_GetSelfInfo(t *testing.T) {
+	requireLive(t)
 	// 创建客户端实例
-	client := NewClient(10, false, false)
+	client := mustNewClient(t, 10, false, false)
 
 	// 启动客户端
-	client.Run(true)
+	if err := client.Run(true); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
 	defer client.Close()
 
 	info, ok := client.GetSelfInfo()
@@ -205,11 +271,14 @@ func TestClient_GetSelfInfo(t *testing.T) {
 }
 
 func TestClient_GetSelfName(t *testing.T) {
+	requireLive(t)
 	// 创建客户端实例
-	client := NewClient(10, false, false)
+	client := mustNewClient(t, 10, false, false)
 
 	// 启动客户端
-	client.Run(false)
+	if err := client.Run(false); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
 	defer client.Close()
 
 	name, ok := client.GetSelfName()
@@ -220,11 +289,14 @@ func TestClient_GetSelfName(t *testing.T) {
 }
 
 func TestClient_GetSelfWxId(t *testing.T) {
+	requireLive(t)
 	// 创建客户端实例
-	client := NewClient(10, false, false)
+	client := mustNewClient(t, 10, false, false)
 
 	// 启动客户端
-	client.Run(false)
+	if err := client.Run(false); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
 	defer client.Close()
 
 	wxid, ok := client.GetSelfWxId()
@@ -307,11 +379,14 @@ func TestClient_GetSelfWxId(t *testing.T) {
 //}
 
 func TestClient_ReplyText(t *testing.T) {
+	requireLive(t)
 	// 创建客户端实例
-	client := NewClient(10, false, false)
+	client := mustNewClient(t, 10, false, false)
 
 	// 启动客户端
-	client.Run(true)
+	if err := client.Run(true); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
 	defer client.Close()
 
 	msg, b := <-client.GetMsgChan()
@@ -330,11 +405,14 @@ func TestClient_ReplyText(t *testing.T) {
 }
 
 func TestClient_IsSendByFriend(t *testing.T) {
+	requireLive(t)
 	// 创建客户端实例
-	client := NewClient(10, false, false)
+	client := mustNewClient(t, 10, false, false)
 
 	// 启动客户端
-	client.Run(false)
+	if err := client.Run(false); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
 	defer client.Close()
 
 	msg, b := <-client.GetMsgChan()
@@ -351,11 +429,14 @@ func TestClient_IsSendByFriend(t *testing.T) {
 }
 
 func TestClient_AcceptNewFriend(t *testing.T) {
+	requireLive(t)
 	// 创建客户端实例
-	client := NewClient(10, false, false)
+	client := mustNewClient(t, 10, false, false)
 
 	// 启动客户端
-	client.Run(false)
+	if err := client.Run(false); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
 	defer client.Close()
 
 	msg, b := <-client.GetMsgChan()
@@ -364,23 +445,30 @@ func TestClient_AcceptNewFriend(t *testing.T) {
 	}
 	t.Logf("收到消息: %+v\n", msg)
 	if nil != msg && msg.Type == MsgTypeFriendConfirm {
-		isSendByFriend := msg.AcceptNewFriend()
-		t.Logf("isFriend: %t", isSendByFriend)
+		if err := msg.AcceptNewFriend(); err != nil {
+			t.Errorf("AcceptNewFriend() err: %v", err)
+		}
 	} else {
 		t.Fatalf("msg is nil")
 	}
 }
 
 func TestClient_GetMemberByCache(t *testing.T) {
+	requireLive(t)
 	// 创建客户端实例
-	client := NewClient(10, false, false)
+	client := mustNewClient(t, 10, false, false)
 
 	// 启动客户端
-	client.Run(false)
+	if err := client.Run(false); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
 	defer client.Close()
 
 	// 假设 "wxid_xxx" 是一个已知的成员
-	member := client.GetMember("45959390469@chatroom", true) // 45959390469@chatroom wxid_qyutq6wnee2f22
+	member, err := client.GetMember("45959390469@chatroom", true) // 45959390469@chatroom wxid_qyutq6wnee2f22
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	if member.Wxid == "" {
 		t.Errorf("GetMember failed: %v", member)
@@ -389,15 +477,19 @@ func TestClient_GetMemberByCache(t *testing.T) {
 }
 
 func TestClient_GetMemberDirectly(t *testing.T) {
+	requireLive(t)
 	// 创建客户端实例
-	client := NewClient(10, false, false)
+	client := mustNewClient(t, 10, false, false)
 
 	// 启动客户端
 	//client.Run(false)
 	defer client.Close()
 
 	// 假设 "wxid_xxx" 是一个已知的成员
-	member := client.GetMember("45959390469@chatroom", false) // 45959390469@chatroom wxid_qyutq6wnee2f22
+	member, err := client.GetMember("45959390469@chatroom", false) // 45959390469@chatroom wxid_qyutq6wnee2f22
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	if member.Wxid == "" {
 		t.Errorf("GetMember failed: %v", member)
@@ -406,11 +498,14 @@ func TestClient_GetMemberDirectly(t *testing.T) {
 }
 
 func TestClient_GetAllMember(t *testing.T) {
+	requireLive(t)
 	// 创建客户端实例
-	client := NewClient(10, false, false)
+	client := mustNewClient(t, 10, false, false)
 
 	// 启动客户端
-	client.Run(true)
+	if err := client.Run(true); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
 	defer client.Close()
 
 	members := client.getAllMember()
@@ -423,11 +518,14 @@ func TestClient_GetAllMember(t *testing.T) {
 
 // TestClient_RecvAndDecodeImageMsg 持续接收消息, 并测试图片消息数据是否携带, 解码并保存图片
 func TestClient_RecvAndDecodeImageMsg(t *testing.T) {
+	requireLive(t)
 	// 创建客户端实例
-	cli := NewClient(10, false, false)
+	cli := mustNewClient(t, 10, false, false)
 
 	// 启动客户端，这里假设不需要自动注入微信
-	cli.Run(true)
+	if err := cli.Run(true); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
 	// 关闭客户端
 	defer cli.Close()
 
@@ -454,8 +552,9 @@ func TestClient_RecvAndDecodeImageMsg(t *testing.T) {
 }
 
 func TestClient_GetSelfFileStoragePath(t *testing.T) {
+	requireLive(t)
 	// 创建客户端实例
-	client := NewClient(10, false, false)
+	client := mustNewClient(t, 10, false, false)
 
 	// 启动客户端
 	//client.Run(false)
@@ -470,11 +569,14 @@ func TestClient_GetSelfFileStoragePath(t *testing.T) {
 
 // TestClient_GetFullFilePathFromRelativePath 测试通过相对路径获取完整文件路径
 func TestClient_GetFullFilePathFromRelativePath(t *testing.T) {
+	requireLive(t)
 	// 创建客户端实例
-	cli := NewClient(10, false, false)
+	cli := mustNewClient(t, 10, false, false)
 
 	// 启动客户端
-	cli.Run(false)
+	if err := cli.Run(false); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
 	// 关闭客户端
 	defer cli.Close()
 
@@ -506,8 +608,9 @@ func TestClient_GetFullFilePathFromRelativePath(t *testing.T) {
 
 // TestClient_SendImage 测试发送图片消息 (需手动验证)
 func TestClient_SendImage(t *testing.T) {
+	requireLive(t)
 	// 创建客户端实例
-	client := NewClient(10, false, false)
+	client := mustNewClient(t, 10, false, false)
 
 	// 启动客户端
 	//client.Run(false)
@@ -549,8 +652,9 @@ func TestClient_SendImage(t *testing.T) {
 }
 
 func TestClient_getAllMember(t *testing.T) {
+	requireLive(t)
 	// 创建客户端实例
-	client := NewClient(10, false, false)
+	client := mustNewClient(t, 10, false, false)
 
 	// 启动客户端
 	//client.Run(true)
@@ -571,8 +675,9 @@ func TestClient_getAllMember(t *testing.T) {
 }
 
 func TestClient_updateCacheInfo(t *testing.T) {
+	requireLive(t)
 	// 创建客户端实例
-	client := NewClient(10, false, false)
+	client := mustNewClient(t, 10, false, false)
 
 	// 启动客户端
 	//client.Run(true)
@@ -582,8 +687,9 @@ func TestClient_updateCacheInfo(t *testing.T) {
 }
 
 func TestClient_SendCardMessage(t *testing.T) {
+	requireLive(t)
 	// 创建客户端实例
-	client := NewClient(10, false, false)
+	client := mustNewClient(t, 10, false, false)
 
 	// 启动客户端
 	//client.Run(false)
@@ -609,3 +715,264 @@ func TestClient_SendCardMessage(t *testing.T) {
 	t.Log("2. 确认图片内容正确")
 	t.Log("3. 如都收到且显示正常，则手动测试通过")
 }
+
+const offlineSelfWxid = "wxid_p5z4fuhnbdgs22"
+
+// newOfflineClient 基于 wcftest 模拟服务端创建客户端
+func newOfflineClient(t *testing.T) (*Client, *wcftest.Server) {
+	t.Helper()
+	srv, err := wcftest.NewServer()
+	if err != nil {
+		t.Fatalf("wcftest.NewServer() error = %v", err)
+	}
+	t.Cleanup(func() { _ = srv.Close() })
+	srv.SetUserInfo(&wcf.UserInfo{Wxid: offlineSelfWxid, Name: "bot", Home: "C:/WeChat Files/"})
+	srv.SetContacts(
+		&wcf.RpcContact{Wxid: "wxid_friend", Name: "friend"},
+		&wcf.RpcContact{Wxid: "45959390469@chatroom", Name: "测试12"},
+		&wcf.RpcContact{Wxid: "gh_official", Name: "公众号"},
+	)
+	t.Setenv(ENVTcpAddr, srv.Addr())
+	cli := mustNewClient(t, 10, false, false)
+	t.Cleanup(cli.Close)
+	return cli, srv
+}
+
+// recvMsg 在超时时间内读取一条消息
+func recvMsg(t *testing.T, cli *Client) *Message {
+	t.Helper()
+	select {
+	case msg := <-cli.GetMsgChan():
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatalf("等待消息超时")
+		return nil
+	}
+}
+
+func TestClient_OfflineRecvText(t *testing.T) {
+	cli, srv := newOfflineClient(t)
+	if err := cli.Run(false); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	err := srv.Push(&wcf.WxMsg{Id: 100, Type: uint32(MsgTypeText), Ts: 1736867627, Sender: "wxid_friend", Content: "ping"})
+	if err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	msg := recvMsg(t, cli)
+	if msg.Content != "ping" || msg.WxId != "wxid_friend" || msg.MessageId != 100 || msg.IsGroup {
+		t.Errorf("recv msg = %+v", msg)
+	}
+	if !srv.RecvEnabled() {
+		t.Errorf("EnableRecvTxt 未被调用")
+	}
+	if err = msg.ReplyText("pong"); err != nil {
+		t.Fatalf("ReplyText() error = %v", err)
+	}
+	reqs := srv.RequestsOf(wcf.Functions_FUNC_SEND_TXT)
+	if len(reqs) != 1 || reqs[0].GetTxt().GetReceiver() != "wxid_friend" || reqs[0].GetTxt().GetMsg() != "pong" {
+		t.Errorf("SendTxt requests = %v", reqs)
+	}
+}
+
+func TestClient_OfflineRecvGroupAt(t *testing.T) {
+	cli, srv := newOfflineClient(t)
+	roomId := "45959390469@chatroom"
+	roomData, err := proto.Marshal(&wcf.RoomData{Members: []*wcf.RoomData_RoomMember{
+		{Wxid: "wxid_friend", Name: "friend"},
+		{Wxid: offlineSelfWxid, Name: "bot"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.SetQuery("MicroMsg.db", "SELECT RoomData FROM ChatRoom WHERE ChatRoomName = '"+roomId+"';", wcftest.BlobRow("RoomData", roomData))
+	srv.SetQuery("MicroMsg.db", "select * from Contact where UserName = 'wxid_friend';", wcftest.Row("UserName", "wxid_friend", "NickName", "friend"))
+	srv.SetQuery("MicroMsg.db", "select * from Contact where UserName = '"+offlineSelfWxid+"';", wcftest.Row("UserName", offlineSelfWxid, "NickName", "bot"))
+	if err := cli.Run(false); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	_ = srv.Push(&wcf.WxMsg{Id: 101, Type: uint32(MsgTypeText), IsGroup: true, Roomid: roomId, Sender: "wxid_friend", Content: "@bot\u2005在吗"})
+	msg := recvMsg(t, cli)
+	if !msg.IsGroup || msg.RoomId != roomId {
+		t.Fatalf("recv msg = %+v", msg)
+	}
+	if len(msg.RoomData.Members) != 2 {
+		t.Errorf("RoomData.Members = %v, want 2 members", msg.RoomData.Members)
+	}
+	if !msg.RoomData.IsAtSelf {
+		t.Errorf("RoomData.IsAtSelf = false, want true")
+	}
+}
+
+func TestClient_OfflineRecvImage(t *testing.T) {
+	cli, srv := newOfflineClient(t)
+	if err := cli.Run(false); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	extra := "C:/WeChat Files/wxid_p5z4fuhnbdgs22/FileStorage/MsgAttach/84d8/Image/2025-02/a.dat"
+	_ = srv.Push(&wcf.WxMsg{Id: 102, Type: uint32(MsgTypeImage), Sender: "wxid_friend", Extra: extra})
+	msg := recvMsg(t, cli)
+	if msg.FileInfo == nil || msg.FileInfo.FilePath != extra || !msg.FileInfo.IsImg {
+		t.Errorf("FileInfo = %+v", msg.FileInfo)
+	}
+	var reqs []*wcf.Request
+	for deadline := time.Now().Add(2 * time.Second); len(reqs) == 0 && time.Now().Before(deadline); { // 图片由附件队列异步下载
+		time.Sleep(10 * time.Millisecond)
+		reqs = srv.RequestsOf(wcf.Functions_FUNC_DOWNLOAD_ATTACH)
+	}
+	if len(reqs) != 1 || reqs[0].GetAtt().GetId() != 102 {
+		t.Errorf("DownloadAttach requests = %v", reqs)
+	}
+}
+
+func TestClient_OfflineSend(t *testing.T) {
+	cli, srv := newOfflineClient(t)
+
+	if err := cli.SendText("wxid_friend", "hi"); err != nil {
+		t.Errorf("SendText() error = %v", err)
+	}
+	if err := cli.SendImage("wxid_friend", "C:/img/a.png"); err != nil {
+		t.Errorf("SendImage() error = %v", err)
+	}
+	if err := cli.SendImageBytes("wxid_friend", []byte{0xFF, 0xD8, 0xFF}); err != nil {
+		t.Errorf("SendImageBytes() error = %v", err)
+	}
+	if err := cli.SendFile("wxid_friend", "C:/file/a.txt"); err != nil {
+		t.Errorf("SendFile() error = %v", err)
+	}
+	if err := cli.SendCardMessage("wxid_friend", CardMessage{Name: "n", Title: "t", URL: "https://example.com"}); err != nil {
+		t.Errorf("SendCardMessage() error = %v", err)
+	}
+	if n := len(srv.RequestsOf(wcf.Functions_FUNC_SEND_IMG)); n != 2 {
+		t.Errorf("SendIMG requests = %d, want 2", n)
+	}
+
+	srv.SetStatus(wcf.Functions_FUNC_SEND_TXT, -1)
+	err := cli.SendText("wxid_friend", "hi")
+	var statusErr *ErrStatus
+	if !errors.As(err, &statusErr) || statusErr.Code != -1 {
+		t.Errorf("SendText() error = %v, want ErrStatus{Code: -1}", err)
+	}
+}
+
+func TestClient_OfflineContacts(t *testing.T) {
+	cli, _ := newOfflineClient(t)
+
+	friends, err := cli.CtFriends()
+	if err != nil || len(friends) != 1 || friends[0].Wxid != "wxid_friend" {
+		t.Errorf("CtFriends() = %v, %v", friends, err)
+	}
+	rooms, err := cli.CtChatRooms()
+	if err != nil || len(rooms) != 1 || rooms[0].RoomID != "45959390469@chatroom" {
+		t.Errorf("CtChatRooms() = %v, %v", rooms, err)
+	}
+	ghs, err := cli.CtGHs()
+	if err != nil || len(ghs) != 1 {
+		t.Errorf("CtGHs() = %v, %v", ghs, err)
+	}
+	wxid, ok := cli.GetSelfWxId()
+	if !ok || wxid != offlineSelfWxid {
+		t.Errorf("GetSelfWxId() = %v, %v", wxid, ok)
+	}
+}
+
+func TestClient_OfflineCtx(t *testing.T) {
+	cli, srv := newOfflineClient(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := cli.SendTextCtx(ctx, "wxid_friend", "hi"); !errors.Is(err, context.Canceled) {
+		t.Errorf("SendTextCtx() with canceled ctx error = %v, want context.Canceled", err)
+	}
+
+	srv.Handle(wcf.Functions_FUNC_EXEC_DB_QUERY, func(req *wcf.Request) *wcf.Response {
+		time.Sleep(200 * time.Millisecond)
+		return nil
+	})
+	cli.SetRPCTimeout(50 * time.Millisecond)
+	if _, err := cli.ExecDBQuery("MicroMsg.db", "select * from Contact;"); !errors.Is(err, ErrTimeout) {
+		t.Errorf("ExecDBQuery() error = %v, want ErrTimeout", err)
+	}
+	cli.SetRPCTimeout(time.Second)
+	if ok, err := cli.IsLogin(); err != nil || !ok {
+		t.Errorf("IsLogin() after timeout = %v, %v, want true, nil", ok, err)
+	}
+}
+
+// waitConnState 在超时时间内等待指定的连接状态
+func waitConnState(t *testing.T, events <-chan ConnEvent, want ConnState) ConnEvent {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				t.Fatalf("连接状态通道已关闭，等待 %s", want)
+			}
+			if ev.State == want {
+				return ev
+			}
+		case <-timeout:
+			t.Fatalf("等待连接状态 %s 超时", want)
+		}
+	}
+}
+
+func TestClient_RunStartupErr(t *testing.T) {
+	cli, srv := newOfflineClient(t)
+	srv.SetStatus(wcf.Functions_FUNC_ENABLE_RECV_TXT, -1)
+	var statusErr *ErrStatus
+	if err := cli.Run(false); !errors.As(err, &statusErr) || statusErr.Func != wcf.Functions_FUNC_ENABLE_RECV_TXT {
+		t.Fatalf("Run() error = %v, want *ErrStatus for FUNC_ENABLE_RECV_TXT", err)
+	}
+	srv.SetStatus(wcf.Functions_FUNC_ENABLE_RECV_TXT, 0)
+	if err := cli.Run(false); err != nil {
+		t.Errorf("Run() after the server recovered error = %v", err)
+	}
+}
+
+func TestClient_OfflineReconnect(t *testing.T) {
+	cli, srv := newOfflineClient(t)
+	cli.reconnectBaseDelay = 10 * time.Millisecond
+	events, unsubscribe := cli.SubscribeConnState(16)
+	defer unsubscribe()
+	if err := cli.Run(false); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	waitConnState(t, events, ConnStateConnected)
+
+	srv.Disconnect()
+	if ev := waitConnState(t, events, ConnStateDisconnected); !errors.Is(ev.Err, ErrTransport) {
+		t.Errorf("Disconnected Err = %v, want ErrTransport", ev.Err)
+	}
+	waitConnState(t, events, ConnStateReconnected)
+	if !srv.RecvEnabled() {
+		t.Errorf("重连后未重新开启消息接收")
+	}
+
+	err := srv.Push(&wcf.WxMsg{Id: 101, Type: uint32(MsgTypeText), Ts: 1736867627, Sender: "wxid_friend", Content: "pong"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg := recvMsg(t, cli); msg.Content != "pong" {
+		t.Errorf("重连后收到消息 %q, want pong", msg.Content)
+	}
+
+	cli.Close()
+	for range events { // 客户端关闭后通道随之关闭
+	}
+}
+
+func TestNewClient_Err(t *testing.T) {
+	t.Setenv(ENVTcpAddr, "tcp://127.0.0.1:1") // 无服务监听
+	if cli, err := NewClient(10, false, false); err == nil || cli != nil {
+		t.Errorf("NewClient() = %v, %v, want error", cli, err)
+	}
+	t.Setenv(ENVTcpAddr, "tcp://127.0.0.1:port")
+	if cli, err := NewClient(10, true, false); err == nil || cli != nil {
+		t.Errorf("NewClient() with invalid port = %v, %v, want error", cli, err)
+	}
+}
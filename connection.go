@@ -0,0 +1,186 @@
+// Package wcf_rpc_sdk
+// @Author Clover
+// @Data 2026/10/16 下午7:10:00
+// @Desc 连接状态通知与断线重连
+package wcf_rpc_sdk
+
+import (
+	"context"
+	"github.com/Clov614/wcf-rpc-sdk/internal/wcf"
+	"sync"
+	"time"
+)
+
+const (
+	defaultReconnectBaseDelay = 500 * time.Millisecond // 首次重连等待时间
+	defaultReconnectMaxDelay  = 30 * time.Second       // 重连等待时间上限
+)
+
+// ConnState 连接状态
+type ConnState uint8
+
+const (
+	ConnStateConnecting   ConnState = iota // 正在连接
+	ConnStateConnected                     // 首次连接成功
+	ConnStateDisconnected                  // 连接断开
+	ConnStateReconnected                   // 断线后重连成功
+)
+
+var connStateNames = map[ConnState]string{
+	ConnStateConnecting:   "Connecting",
+	ConnStateConnected:    "Connected",
+	ConnStateDisconnected: "Disconnected",
+	ConnStateReconnected:  "Reconnected",
+}
+
+func (s ConnState) String() string {
+	if name, ok := connStateNames[s]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// ConnEvent 连接状态事件
+type ConnEvent struct {
+	State   ConnState `json:"state"`
+	Attempt int       `json:"attempt,omitempty"` // 本轮第几次尝试连接，仅 Connecting 时有值
+	Err     error     `json:"-"`                 // 断开原因，仅 Disconnected 时有值
+	Time    time.Time `json:"time"`
+}
+
+// connNotifier 连接状态事件分发
+type connNotifier struct {
+	mu     sync.Mutex
+	subs   map[chan ConnEvent]struct{}
+	closed bool
+	logger Logger
+}
+
+func newConnNotifier(logger Logger) *connNotifier {
+	return &connNotifier{subs: make(map[chan ConnEvent]struct{}), logger: logger}
+}
+
+func (n *connNotifier) subscribe(size int) (<-chan ConnEvent, func()) {
+	ch := make(chan ConnEvent, size)
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	n.subs[ch] = struct{}{}
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			n.mu.Lock()
+			defer n.mu.Unlock()
+			if _, ok := n.subs[ch]; ok {
+				delete(n.subs, ch)
+				close(ch)
+			}
+		})
+	}
+}
+
+// emit 非阻塞分发，订阅者通道已满时丢弃该事件
+func (n *connNotifier) emit(ev ConnEvent) {
+	ev.Time = time.Now()
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for ch := range n.subs {
+		select {
+		case ch <- ev:
+		default:
+			n.logger.Debug("conn event dropped", map[string]interface{}{"state": ev.State.String()})
+		}
+	}
+}
+
+func (n *connNotifier) close() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		return
+	}
+	n.closed = true
+	for ch := range n.subs {
+		delete(n.subs, ch)
+		close(ch)
+	}
+}
+
+// SubscribeConnState 订阅连接状态事件 <通道缓冲大小>，返回取消订阅的方法；通道已满时新事件会被丢弃，客户端关闭时通道关闭
+func (c *Client) SubscribeConnState(size int) (<-chan ConnEvent, func()) {
+	return c.connNotifier.subscribe(size)
+}
+
+// supervise 保持消息接收，断开后按指数退避重连：重新拨号、开启消息接收并恢复投递
+// 首次连接的结果发送到 started，首次连接失败时不再重连
+func (c *Client) supervise(ctx context.Context, handler wcf.MsgHandler, started chan<- error) {
+	report := func(err error) {
+		if started != nil {
+			started <- err
+			started = nil
+		}
+	}
+	var (
+		online        bool // 当前是否在线
+		everConnected bool // 是否曾连接成功
+		attempt       int
+		delay         = c.reconnectBaseDelay
+	)
+	for {
+		attempt++
+		c.connNotifier.emit(ConnEvent{State: ConnStateConnecting, Attempt: attempt})
+		err := c.connect(ctx, everConnected || attempt > 1)
+		if err == nil {
+			state := ConnStateConnected
+			if everConnected {
+				state = ConnStateReconnected
+			}
+			err = c.wxClient.OnMSGReady(ctx, handler, func() { // 当消息到来时，处理消息
+				online, everConnected = true, true
+				attempt, delay = 0, c.reconnectBaseDelay
+				report(nil)
+				c.connNotifier.emit(ConnEvent{State: state})
+				c.logger.Info("wcf 连接成功", map[string]interface{}{"state": state.String()})
+			})
+			if err == nil { // 已主动关闭消息接收
+				report(nil)
+				return
+			}
+		}
+		if ctx.Err() != nil {
+			report(ctx.Err())
+			return
+		}
+		if !everConnected { // 启动失败交由 Run 返回
+			report(err)
+			return
+		}
+		if online {
+			online = false
+			c.connNotifier.emit(ConnEvent{State: ConnStateDisconnected, Err: err})
+		}
+		c.logger.Warn(err, "wcf 连接异常，稍后重连", map[string]interface{}{"attempt": attempt, "delay": delay.String()})
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > c.reconnectMaxDelay {
+			delay = c.reconnectMaxDelay
+		}
+	}
+}
+
+// connect 开启消息接收 <是否重新拨号命令端口>
+func (c *Client) connect(ctx context.Context, redial bool) error {
+	if redial {
+		if err := c.wxClient.Redial(); err != nil {
+			return err
+		}
+	}
+	_, err := c.wxClient.EnableRecvTxtCtx(ctx) // 允许接收消息
+	return err
+}
@@ -0,0 +1,66 @@
+// Package wcf_rpc_sdk
+// @Author Clover
+// @Data 2026/10/17 上午12:10:00
+// @Desc 通过 FUNC_GET_CONTACT_INFO 查询单个联系人
+package wcf_rpc_sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Clov614/wcf-rpc-sdk/internal/wcf"
+)
+
+var ErrContactNotFound = errors.New("contact not found")
+
+// GetContactInfo 查询单个联系人，同 GetMemberCtx 不走缓存，不存在时返回 ErrContactNotFound
+func (c *Client) GetContactInfo(ctx context.Context, wxid string) (*ContactInfo, error) {
+	info, err := c.GetMemberCtx(ctx, wxid, false)
+	if err != nil {
+		return nil, err
+	}
+	if info.Wxid == "" {
+		return nil, ErrContactNotFound
+	}
+	return info, nil
+}
+
+// queryContact 优先使用 FUNC_GET_CONTACT_INFO，仅在服务端不支持该接口时改为查询数据库，不存在时返回空的 ContactInfo
+func (c *Client) queryContact(ctx context.Context, wxid string) (*ContactInfo, error) {
+	if !c.contactInfoUnsupported.Load() {
+		contact, err := c.wxClient.GetContactInfoCtx(ctx, wxid)
+		switch {
+		case err == nil && contact == nil:
+			return &ContactInfo{}, nil
+		case err == nil:
+			return c.contactFromRPC(ctx, contact), nil
+		case !errors.Is(err, wcf.ErrUnsupported):
+			return nil, fmt.Errorf("wxClient.GetContactInfo: %w", err)
+		}
+		c.contactInfoUnsupported.Store(true) // 服务端版本不会在运行中变化，之后直接查询数据库
+		c.logger.Info("FUNC_GET_CONTACT_INFO 不受支持，改为查询数据库")
+	}
+	return c.queryContactDB(ctx, wxid)
+}
+
+// contactFromRPC 以缓存为底补全 RPC 未返回的字段并更新缓存
+// RpcContact 中没有头像，缓存中已有头像时沿用，否则查询一次 ContactHeadImgUrl
+func (c *Client) contactFromRPC(ctx context.Context, contact *wcf.RpcContact) *ContactInfo {
+	info := &ContactInfo{}
+	if cached, ok := c.cacheMember.GetContactInfo(contact.GetWxid()); ok && cached != nil {
+		*info = *cached
+	}
+	info.Wxid = contact.GetWxid()
+	info.Alias = contact.GetCode()
+	info.Remark = contact.GetRemark()
+	info.NickName = contact.GetName()
+	info.Country = contact.GetCountry()
+	info.Province = contact.GetProvince()
+	info.City = contact.GetCity()
+	info.Gender = contact.GetGender()
+	if info.SmallHeadURL == "" && info.BigHeadURL == "" {
+		c.queryHeadImg(ctx, info)
+	}
+	c.cacheMember.CacheContactInfo(info)
+	return info
+}
@@ -0,0 +1,108 @@
+package wcf_rpc_sdk
+
+import (
+	"context"
+	"errors"
+	"github.com/Clov614/wcf-rpc-sdk/internal/wcf"
+	"github.com/Clov614/wcf-rpc-sdk/internal/wcftest"
+	"google.golang.org/protobuf/proto"
+	"strings"
+	"testing"
+)
+
+func dbRow(kv ...string) *wcf.DbRow {
+	row := &wcf.DbRow{}
+	for i := 0; i+1 < len(kv); i += 2 {
+		row.Fields = append(row.Fields, &wcf.DbField{Column: kv[i], Content: []byte(kv[i+1])})
+	}
+	return row
+}
+
+func TestClient_GetContactInfo(t *testing.T) {
+	cli, srv := newOfflineClient(t)
+	srv.SetContacts(&wcf.RpcContact{Wxid: "wxid_friend", Code: "friend_code", Remark: "老友", Name: "friend", City: "Hangzhou", Gender: 1})
+	srv.SetQuery("MicroMsg.db", "select * from ContactHeadImgUrl where usrName = 'wxid_friend';", dbRow("smallHeadImgUrl", "http://s", "bigHeadImgUrl", "http://b"))
+
+	info, err := cli.GetContactInfo(context.Background(), "wxid_friend")
+	if err != nil {
+		t.Fatalf("GetContactInfo() error = %v", err)
+	}
+	want := ContactInfo{Wxid: "wxid_friend", Alias: "friend_code", Remark: "老友", NickName: "friend", City: "Hangzhou", Gender: 1, SmallHeadURL: "http://s", BigHeadURL: "http://b"}
+	if *info != want {
+		t.Errorf("GetContactInfo() = %+v, want %+v", *info, want)
+	}
+	if _, err = cli.GetContactInfo(context.Background(), "wxid_nobody"); !errors.Is(err, ErrContactNotFound) {
+		t.Errorf("GetContactInfo() error = %v, want ErrContactNotFound", err)
+	}
+}
+
+func TestClient_GetContactInfoFallback(t *testing.T) {
+	cli, srv := newOfflineClient(t)
+	srv.Handle(wcf.Functions_FUNC_GET_CONTACT_INFO, func(req *wcf.Request) *wcf.Response {
+		return wcftest.Status(0) // 旧版本服务端不认识该接口
+	})
+	srv.SetQuery("MicroMsg.db", "select * from Contact where UserName = 'wxid_old';", dbRow("UserName", "wxid_old", "NickName", "old", "Remark", "旧"))
+
+	for i := 0; i < 2; i++ {
+		info, err := cli.GetContactInfo(context.Background(), "wxid_old")
+		if err != nil || info.NickName != "old" || info.Remark != "旧" {
+			t.Fatalf("GetContactInfo() = %+v, %v", info, err)
+		}
+	}
+	if n := len(srv.RequestsOf(wcf.Functions_FUNC_GET_CONTACT_INFO)); n != 1 {
+		t.Errorf("contact info requests = %d, want 1 before falling back", n)
+	}
+	if _, err := cli.GetContactInfo(context.Background(), "wxid_nobody"); !errors.Is(err, ErrContactNotFound) {
+		t.Errorf("GetContactInfo() error = %v, want ErrContactNotFound", err)
+	}
+}
+
+// contactQueries 返回查询 Contact、ContactHeadImgUrl 表的 SQL
+func contactQueries(srv *wcftest.Server) []string {
+	var sqls []string
+	for _, req := range srv.RequestsOf(wcf.Functions_FUNC_EXEC_DB_QUERY) {
+		if sql := req.GetQuery().GetSql(); strings.Contains(sql, "from Contact") {
+			sqls = append(sqls, sql)
+		}
+	}
+	return sqls
+}
+
+func TestClient_RoomMembersByRPC(t *testing.T) {
+	cli, srv := newOfflineClient(t)
+	const roomId = "45959390469@chatroom"
+	roomData, err := proto.Marshal(&wcf.RoomData{Members: []*wcf.RoomData_RoomMember{{Wxid: "wxid_friend", Name: "群昵称"}}})
+	if err != nil {
+		t.Fatalf("proto.Marshal() error = %v", err)
+	}
+	srv.SetQuery("MicroMsg.db", "SELECT RoomData FROM ChatRoom WHERE ChatRoomName = '"+roomId+"';", &wcf.DbRow{Fields: []*wcf.DbField{{Column: "RoomData", Content: roomData}}})
+	cli.cacheMember.CacheContactInfo(&ContactInfo{Wxid: "wxid_friend", SmallHeadURL: "http://s"}) // 头像取自缓存
+
+	info, err := cli.GetMember("wxid_friend", false)
+	if err != nil || info.NickName != "friend" || info.SmallHeadURL != "http://s" {
+		t.Fatalf("GetMember() = %+v, %v", info, err)
+	}
+	members, err := cli.RoomMembers(roomId)
+	if err != nil || len(members) != 1 || members[0].NickName != "friend" {
+		t.Fatalf("RoomMembers() = %v, %v", members, err)
+	}
+	if sqls := contactQueries(srv); len(sqls) != 0 {
+		t.Errorf("contact queries = %q, want none when FUNC_GET_CONTACT_INFO is supported", sqls)
+	}
+}
+
+func TestClient_GetMemberFallbackHeadImg(t *testing.T) {
+	cli, srv := newOfflineClient(t)
+	srv.Handle(wcf.Functions_FUNC_GET_CONTACT_INFO, func(req *wcf.Request) *wcf.Response {
+		return wcftest.Status(0)
+	})
+	srv.SetQuery("MicroMsg.db", "select * from Contact where UserName = 'wxid_old';", dbRow("UserName", "wxid_old", "NickName", "old", "SmallHeadImgUrl", "http://s"))
+
+	info, err := cli.GetMember("wxid_old", false)
+	if err != nil || info.NickName != "old" || info.SmallHeadURL != "http://s" {
+		t.Fatalf("GetMember() = %+v, %v", info, err)
+	}
+	if sqls := contactQueries(srv); len(sqls) != 1 {
+		t.Errorf("contact queries = %q, want only the Contact query when the row has a head image", sqls)
+	}
+}
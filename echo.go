@@ -0,0 +1,197 @@
+// Package wcf_rpc_sdk
+// @Author Clover
+// @Data 2026/10/16 下午10:50:00
+// @Desc 撤回消息，通过自己发出消息的回显获取消息 id
+package wcf_rpc_sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Clov614/wcf-rpc-sdk/internal/wcf"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultEchoTimeout ctx 未设置 deadline 时等待回显的时间
+const DefaultEchoTimeout = 10 * time.Second
+
+// ErrEchoTimeout 消息已发送，但未在超时前收到回显，无法得知消息 id
+var ErrEchoTimeout = errors.New("sent message echo not received")
+
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// echoMatch 匹配自己发出消息的回显
+type echoMatch struct {
+	receiver string  // 接收人 wxid 或 roomid，回显中为 Roomid
+	msgType  MsgType // 回显消息类型
+	content  string  // 非空时要求内容完全一致
+	contains string  // 非空时要求内容包含
+	serial   bool    // 回显内容不足以区分时，同一接收人、同一类型的发送排队进行
+}
+
+func (m echoMatch) match(msg *wcf.WxMsg) bool {
+	if !msg.IsSelf || msg.Roomid != m.receiver || MsgType(msg.Type) != m.msgType {
+		return false
+	}
+	if m.content != "" && msg.Content != m.content {
+		return false
+	}
+	return m.contains == "" || strings.Contains(msg.Content, m.contains)
+}
+
+type echoWaiter struct {
+	echoMatch
+	id chan uint64
+}
+
+// echoRegistry 等待回显的发送请求，按注册顺序匹配
+type echoRegistry struct {
+	mu      sync.Mutex
+	waiters []*echoWaiter
+	sending map[string]*echoLane // 排队发送的锁，key 为接收人与消息类型，无人使用时删除
+}
+
+// echoLane 同一接收人、同一类型的发送队列
+type echoLane struct {
+	sem  chan struct{}
+	refs int // 持有或等待该锁的发送数
+}
+
+// acquire 等待同一接收人、同一类型的上一条发送收到回显或超时
+func (r *echoRegistry) acquire(ctx context.Context, m echoMatch) (release func(), err error) {
+	key := fmt.Sprintf("%s:%d", m.receiver, m.msgType)
+	r.mu.Lock()
+	if r.sending == nil {
+		r.sending = make(map[string]*echoLane)
+	}
+	lane, ok := r.sending[key]
+	if !ok {
+		lane = &echoLane{sem: make(chan struct{}, 1)}
+		r.sending[key] = lane
+	}
+	lane.refs++
+	r.mu.Unlock()
+	leave := func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if lane.refs--; lane.refs == 0 {
+			delete(r.sending, key)
+		}
+	}
+	select {
+	case lane.sem <- struct{}{}:
+		return func() {
+			<-lane.sem
+			leave()
+		}, nil
+	case <-ctx.Done():
+		leave()
+		return nil, fmt.Errorf("wait previous echo: %w", ctx.Err())
+	}
+}
+
+func (r *echoRegistry) add(m echoMatch) *echoWaiter {
+	w := &echoWaiter{echoMatch: m, id: make(chan uint64, 1)}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.waiters = append(r.waiters, w)
+	return w
+}
+
+func (r *echoRegistry) remove(w *echoWaiter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, waiter := range r.waiters {
+		if waiter == w {
+			r.waiters = append(r.waiters[:i], r.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+// dispatch 将回显的消息 id 交给最早注册且匹配的发送请求
+func (r *echoRegistry) dispatch(msg *wcf.WxMsg) {
+	if !msg.IsSelf {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, w := range r.waiters {
+		if w.match(msg) {
+			w.id <- msg.Id
+			r.waiters = append(r.waiters[:i], r.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+// sendWithEcho 先登记再发送，避免回显早于发送应答到达
+func (c *Client) sendWithEcho(ctx context.Context, m echoMatch, send func(ctx context.Context) error) (uint64, error) {
+	if m.serial {
+		release, err := c.echo.acquire(ctx, m)
+		if err != nil {
+			return 0, err
+		}
+		defer release()
+	}
+	w := c.echo.add(m)
+	defer c.echo.remove(w)
+	if err := send(ctx); err != nil {
+		return 0, err
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultEchoTimeout)
+		defer cancel()
+	}
+	select {
+	case id := <-w.id:
+		return id, nil
+	case <-ctx.Done():
+		return 0, fmt.Errorf("%w: %w", ErrEchoTimeout, ctx.Err())
+	}
+}
+
+// SendTextWithId 同 SendTextCtx，并返回已发送消息的 id（需已 Run 接收消息）
+func (c *Client) SendTextWithId(ctx context.Context, receiver string, content string, ats ...string) (uint64, error) {
+	content, atList, err := c.atText(ctx, content, ats)
+	if err != nil {
+		return 0, err
+	}
+	return c.sendWithEcho(ctx, echoMatch{receiver: receiver, msgType: MsgTypeText, content: content}, func(ctx context.Context) error {
+		return c.sendText(ctx, receiver, content, atList)
+	})
+}
+
+// SendImageWithId 同 SendImageCtx，并返回已发送消息的 id（需已 Run 接收消息）
+// 图片回显中没有可匹配的内容，发给同一接收人的图片会排队，待上一张收到回显后再发送
+func (c *Client) SendImageWithId(ctx context.Context, receiver string, src string) (uint64, error) {
+	return c.sendWithEcho(ctx, echoMatch{receiver: receiver, msgType: MsgTypeImage, serial: true}, func(ctx context.Context) error {
+		return c.SendImageCtx(ctx, receiver, src)
+	})
+}
+
+// SendFileWithId 同 SendFileCtx，并返回已发送消息的 id（需已 Run 接收消息）
+// 回显按文件名匹配，同名文件无法区分，发给同一接收人的文件同样排队发送
+func (c *Client) SendFileWithId(ctx context.Context, receiver string, src string) (uint64, error) {
+	name := src[strings.LastIndexAny(src, `/\`)+1:] // src 为微信所在主机上的路径
+	m := echoMatch{receiver: receiver, msgType: MsgTypeXML, contains: "<title>" + xmlEscaper.Replace(name) + "</title>", serial: true}
+	return c.sendWithEcho(ctx, m, func(ctx context.Context) error {
+		return c.SendFileCtx(ctx, receiver, src)
+	})
+}
+
+// RevokeMessage 撤回自己发出的消息 <消息 id>
+func (c *Client) RevokeMessage(msgId uint64) error {
+	return c.RevokeMessageCtx(context.Background(), msgId)
+}
+
+// RevokeMessageCtx 同 RevokeMessage，支持 ctx 取消与超时
+func (c *Client) RevokeMessageCtx(ctx context.Context, msgId uint64) error {
+	if _, err := c.wxClient.RevokeMsgCtx(ctx, msgId); err != nil {
+		return fmt.Errorf("wxClient.RevokeMsg: %w", err)
+	}
+	return nil
+}
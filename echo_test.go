@@ -0,0 +1,141 @@
+package wcf_rpc_sdk
+
+import (
+	"context"
+	"errors"
+	"github.com/Clov614/wcf-rpc-sdk/internal/wcf"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClient_SendWithIdAndRevoke(t *testing.T) {
+	cli, srv := newOfflineClient(t)
+	if err := cli.Run(false); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	var nextId uint64 = 500
+	echo := func(typ MsgType, content func(req *wcf.Request) string) func(req *wcf.Request) *wcf.Response {
+		return func(req *wcf.Request) *wcf.Response {
+			nextId++
+			receiver := req.GetTxt().GetReceiver() + req.GetFile().GetReceiver()
+			_ = srv.Push(&wcf.WxMsg{Id: nextId, IsSelf: true, Type: uint32(typ), Roomid: receiver, Sender: offlineSelfWxid, Content: content(req)})
+			return nil
+		}
+	}
+	srv.Handle(wcf.Functions_FUNC_SEND_TXT, echo(MsgTypeText, func(req *wcf.Request) string { return req.GetTxt().GetMsg() }))
+	srv.Handle(wcf.Functions_FUNC_SEND_FILE, echo(MsgTypeXML, func(req *wcf.Request) string {
+		return "<msg><appmsg><title>a&amp;b.txt</title><type>6</type></appmsg></msg>"
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	textId, err := cli.SendTextWithId(ctx, "wxid_friend", "wrong reply")
+	if err != nil || textId != 501 {
+		t.Fatalf("SendTextWithId() = %d, %v, want 501", textId, err)
+	}
+	fileId, err := cli.SendFileWithId(ctx, "wxid_friend", `C:\files\a&b.txt`)
+	if err != nil || fileId != 502 {
+		t.Fatalf("SendFileWithId() = %d, %v, want 502", fileId, err)
+	}
+
+	if err = cli.RevokeMessage(textId); err != nil {
+		t.Fatalf("RevokeMessage() error = %v", err)
+	}
+	reqs := srv.RequestsOf(wcf.Functions_FUNC_REVOKE_MSG)
+	if len(reqs) != 1 || reqs[0].GetUi64() != textId {
+		t.Errorf("revoke requests = %v", reqs)
+	}
+	srv.SetStatus(wcf.Functions_FUNC_REVOKE_MSG, 0)
+	var statusErr *ErrStatus
+	if err = cli.RevokeMessage(fileId); !errors.As(err, &statusErr) {
+		t.Errorf("RevokeMessage() error = %v, want *ErrStatus", err)
+	}
+}
+
+func TestClient_SendWithIdNoEcho(t *testing.T) {
+	cli, _ := newOfflineClient(t)
+	if err := cli.Run(false); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if _, err := cli.SendTextWithId(ctx, "wxid_friend", "hi"); !errors.Is(err, ErrEchoTimeout) {
+		t.Errorf("SendTextWithId() error = %v, want ErrEchoTimeout", err)
+	}
+}
+
+func TestClient_SendImageWithIdConcurrent(t *testing.T) {
+	cli, srv := newOfflineClient(t)
+	if err := cli.Run(false); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	var (
+		mu     sync.Mutex
+		nextId uint64 = 600
+		ids           = make(map[string]uint64) // 图片路径 -> 回显的消息 id
+	)
+	srv.Handle(wcf.Functions_FUNC_SEND_IMG, func(req *wcf.Request) *wcf.Response {
+		mu.Lock()
+		nextId++
+		id, first := nextId, nextId == 601
+		ids[req.GetFile().GetPath()] = id
+		mu.Unlock()
+		echo := &wcf.WxMsg{Id: id, IsSelf: true, Type: uint32(MsgTypeImage), Roomid: req.GetFile().GetReceiver(), Sender: offlineSelfWxid}
+		go func() {
+			if first { // 第一张图片的回显晚于第二张的发送
+				time.Sleep(100 * time.Millisecond)
+			}
+			_ = srv.Push(echo)
+		}()
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	paths := []string{`C:\img\a.jpg`, `C:\img\b.jpg`}
+	got := make([]uint64, len(paths))
+	var wg sync.WaitGroup
+	for i, p := range paths {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id, err := cli.SendImageWithId(ctx, "wxid_friend", p)
+			if err != nil {
+				t.Errorf("SendImageWithId(%s) error = %v", p, err)
+			}
+			got[i] = id
+		}()
+	}
+	wg.Wait()
+	cli.echo.mu.Lock()
+	if n := len(cli.echo.sending); n != 0 {
+		t.Errorf("echo lanes = %d after all sends finished, want 0", n)
+	}
+	cli.echo.mu.Unlock()
+	mu.Lock()
+	defer mu.Unlock()
+	for i, p := range paths {
+		if got[i] != ids[p] {
+			t.Errorf("SendImageWithId(%s) = %d, want %d", p, got[i], ids[p])
+		}
+	}
+}
+
+func TestEchoRegistry_LaneReleased(t *testing.T) {
+	var r echoRegistry
+	m := echoMatch{receiver: "wxid_friend", msgType: MsgTypeImage}
+	release, err := r.acquire(context.Background(), m)
+	if err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err = r.acquire(ctx, m); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("acquire() while held error = %v, want DeadlineExceeded", err)
+	}
+	release()
+	if n := len(r.sending); n != 0 {
+		t.Errorf("echo lanes = %d after release, want 0", n)
+	}
+}
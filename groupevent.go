@@ -0,0 +1,253 @@
+// Package wcf_rpc_sdk
+// @Author Clover
+// @Data 2026/10/17 上午3:40:00
+// @Desc 群系统消息（入群、移出、改名、转让群主、群公告、撤回）解析为群事件
+package wcf_rpc_sdk
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// GroupEvent 群事件，按具体类型断言：
+// *MemberJoined、*MemberLeft、*RoomRenamed、*OwnerChanged、*AnnouncementChanged、*MessageRevoked
+type GroupEvent interface {
+	groupEvent()
+}
+
+// EventMember 事件涉及的成员，wxid 取自 sysmsg 模板或群成员缓存，匹配不到时为空
+type EventMember struct {
+	Wxid     string `json:"wxid,omitempty"`
+	NickName string `json:"nick_name"`
+}
+
+// MemberJoined 成员入群
+type MemberJoined struct {
+	Inviter  EventMember   `json:"inviter"`   // 邀请人，扫码入群时为分享二维码的人
+	Members  []EventMember `json:"members"`   // 入群的成员
+	ByQRCode bool          `json:"by_qrcode"` // 是否通过扫描二维码入群
+}
+
+// MemberLeft 成员被移出群聊
+type MemberLeft struct {
+	Operator EventMember   `json:"operator"`
+	Members  []EventMember `json:"members"`
+}
+
+// RoomRenamed 群名修改
+type RoomRenamed struct {
+	Operator EventMember `json:"operator"`
+	Old      string      `json:"old,omitempty"` // 取自联系人缓存，缓存中没有时为空
+	New      string      `json:"new"`
+}
+
+// OwnerChanged 群主转让
+type OwnerChanged struct {
+	NewOwner EventMember `json:"new_owner"`
+}
+
+// AnnouncementChanged 群公告修改
+type AnnouncementChanged struct {
+	Operator EventMember `json:"operator"`
+	Content  string      `json:"content"`
+}
+
+// MessageRevoked 撤回消息，私聊中同样会解析
+type MessageRevoked struct {
+	MsgId uint64      `json:"msg_id,omitempty"` // 被撤回消息的 id，仅 sysmsg 中有
+	By    EventMember `json:"by"`
+}
+
+func (*MemberJoined) groupEvent()        {}
+func (*MemberLeft) groupEvent()          {}
+func (*RoomRenamed) groupEvent()         {}
+func (*OwnerChanged) groupEvent()        {}
+func (*AnnouncementChanged) groupEvent() {}
+func (*MessageRevoked) groupEvent()      {}
+
+const selfName = "你" // 系统消息中对自己的称呼
+
+var (
+	reInvite         = regexp.MustCompile(`^(你|"(.+?)")邀请(你|"(.+)")加入了群聊`)
+	reQRCode         = regexp.MustCompile(`^(你|"(.+?)")通过扫描(你|"(.+?)")分享的二维码加入群聊`)
+	reKickOut        = regexp.MustCompile(`^(你|"(.+?)")将"(.+)"移出了群聊`)
+	reKickedOut      = regexp.MustCompile(`^你被"(.+?)"移出群聊`)
+	reRename         = regexp.MustCompile(`^(你|"(.+?)")修改群名为[“"](.+)[”"]$`)
+	reOwner          = regexp.MustCompile(`^(你|"(.+?)")已成为新群主`)
+	reRevoke         = regexp.MustCompile(`^(你|"(.+?)") ?撤回了一条消息`)
+	reAnnouncement   = regexp.MustCompile(`^(你|"(.+?)")修改了?群公告`)
+	templateVariable = regexp.MustCompile(`\$(\w+)\$`)
+)
+
+const memberSeparator = "、"
+
+// memberResolver 将昵称解析为成员
+type memberResolver func(name string) EventMember
+
+// eventName 取正则中「你」或引号内的昵称
+func eventName(whole, quoted string) string {
+	if whole == selfName {
+		return selfName
+	}
+	return quoted
+}
+
+func (r memberResolver) list(names string) []EventMember {
+	var members []EventMember
+	for _, n := range strings.Split(names, memberSeparator) {
+		if n = strings.Trim(n, `"`); n != "" {
+			members = append(members, r(n))
+		}
+	}
+	return members
+}
+
+// parseGroupEventText 按系统消息文本识别群事件，未识别时返回 nil
+func parseGroupEventText(text string, resolve memberResolver) GroupEvent {
+	text = strings.TrimSpace(text)
+	if s := reInvite.FindStringSubmatch(text); s != nil {
+		return &MemberJoined{Inviter: resolve(eventName(s[1], s[2])), Members: resolve.list(eventName(s[3], s[4]))}
+	}
+	if s := reQRCode.FindStringSubmatch(text); s != nil {
+		return &MemberJoined{Inviter: resolve(eventName(s[3], s[4])), Members: resolve.list(eventName(s[1], s[2])), ByQRCode: true}
+	}
+	if s := reKickOut.FindStringSubmatch(text); s != nil {
+		return &MemberLeft{Operator: resolve(eventName(s[1], s[2])), Members: resolve.list(s[3])}
+	}
+	if s := reKickedOut.FindStringSubmatch(text); s != nil {
+		return &MemberLeft{Operator: resolve(s[1]), Members: []EventMember{resolve(selfName)}}
+	}
+	if s := reRename.FindStringSubmatch(text); s != nil {
+		return &RoomRenamed{Operator: resolve(eventName(s[1], s[2])), New: s[3]}
+	}
+	if s := reOwner.FindStringSubmatch(text); s != nil {
+		return &OwnerChanged{NewOwner: resolve(eventName(s[1], s[2]))}
+	}
+	if s := reRevoke.FindStringSubmatch(text); s != nil {
+		return &MessageRevoked{By: resolve(eventName(s[1], s[2]))}
+	}
+	if s := reAnnouncement.FindStringSubmatch(text); s != nil {
+		return &AnnouncementChanged{Operator: resolve(eventName(s[1], s[2]))}
+	}
+	return nil
+}
+
+// sysMsg type 10002 的 sysmsg
+type sysMsg struct {
+	Type      string `xml:"type,attr"`
+	RevokeMsg struct {
+		Session    string `xml:"session"`
+		NewMsgId   string `xml:"newmsgid"`
+		ReplaceMsg string `xml:"replacemsg"`
+	} `xml:"revokemsg"`
+	Template struct {
+		Template string `xml:"template"`
+		Links    []struct {
+			Name    string `xml:"name,attr"`
+			Members []struct {
+				Username string `xml:"username"`
+				NickName string `xml:"nickname"`
+			} `xml:"memberlist>member"`
+		} `xml:"link_list>link"`
+	} `xml:"sysmsgtemplate>content_template"`
+	Announcement struct {
+		Content string `xml:"content"`
+	} `xml:"mmchatroombarannouncememt"` // 微信原有拼写
+	Pat patXml `xml:"pat"`
+}
+
+// parseSysMsg 解析 sysmsg XML，群聊中内容可能带有 "roomid:\n" 前缀
+func parseSysMsg(content string) (*sysMsg, error) {
+	start := strings.Index(content, "<sysmsg")
+	if start < 0 {
+		return nil, nil
+	}
+	var sm sysMsg
+	if err := xml.Unmarshal([]byte(content[start:]), &sm); err != nil {
+		return nil, fmt.Errorf("xml.Unmarshal sysmsg: %w", err)
+	}
+	return &sm, nil
+}
+
+// groupEventFromSysMsg 由 sysmsg 识别群事件，模板中的成员 wxid 优先于 resolve 的结果
+func groupEventFromSysMsg(sm *sysMsg, resolve memberResolver) GroupEvent {
+	switch sm.Type {
+	case "revokemsg":
+		ev, _ := parseGroupEventText(sm.RevokeMsg.ReplaceMsg, resolve).(*MessageRevoked)
+		if ev == nil {
+			ev = &MessageRevoked{}
+		}
+		ev.MsgId, _ = strconv.ParseUint(strings.TrimSpace(sm.RevokeMsg.NewMsgId), 10, 64)
+		return ev
+	case "mmchatroombarannouncememt":
+		return &AnnouncementChanged{Content: sm.Announcement.Content}
+	case "sysmsgtemplate":
+		t := sm.Template
+		known := make(map[string]string) // 昵称 -> wxid
+		vars := make(map[string]string)
+		for _, link := range t.Links {
+			names := make([]string, 0, len(link.Members))
+			for _, m := range link.Members {
+				names = append(names, m.NickName)
+				known[m.NickName] = m.Username
+			}
+			vars[link.Name] = strings.Join(names, memberSeparator)
+		}
+		text := templateVariable.ReplaceAllStringFunc(t.Template, func(v string) string {
+			return vars[strings.Trim(v, "$")]
+		})
+		return parseGroupEventText(text, func(n string) EventMember {
+			if wxid, ok := known[n]; ok && n != selfName {
+				return EventMember{Wxid: wxid, NickName: n}
+			}
+			return resolve(n)
+		})
+	}
+	return nil
+}
+
+// resolveMember 按「你」或消息所在群的成员昵称、备注解析成员
+func (c *Client) resolveMember(m *Message) memberResolver {
+	return func(n string) EventMember {
+		if n == selfName {
+			wxid, _ := c.GetSelfWxId()
+			return EventMember{Wxid: wxid, NickName: n}
+		}
+		if m.RoomData != nil {
+			for _, member := range m.RoomData.Members {
+				if member != nil && (member.NickName == n || member.Remark == n) {
+					return EventMember{Wxid: member.Wxid, NickName: n}
+				}
+			}
+		}
+		return EventMember{NickName: n}
+	}
+}
+
+// parseGroupEvent 解析 type 10000、10002 的系统消息
+func (c *Client) parseGroupEvent(m *Message) GroupEvent {
+	resolve := c.resolveMember(m)
+	var ev GroupEvent
+	if strings.Contains(m.Content, "<sysmsg") {
+		sm, err := parseSysMsg(m.Content)
+		if err != nil {
+			c.logger.Debug("parseSysMsg", map[string]interface{}{"err": err, "content": m.Content})
+			return nil
+		}
+		if m.RoomId == "" && strings.HasSuffix(sm.RevokeMsg.Session, "@chatroom") {
+			m.RoomId = sm.RevokeMsg.Session
+		}
+		ev = groupEventFromSysMsg(sm, resolve)
+	} else {
+		ev = parseGroupEventText(m.Content, resolve)
+	}
+	if renamed, ok := ev.(*RoomRenamed); ok && m.RoomId != "" {
+		if room, ok := c.cacheMember.GetContactInfo(m.RoomId); ok && room != nil {
+			renamed.Old = room.NickName
+		}
+	}
+	return ev
+}
@@ -0,0 +1,120 @@
+package wcf_rpc_sdk
+
+import (
+	"github.com/Clov614/wcf-rpc-sdk/internal/wcf"
+	"reflect"
+	"testing"
+)
+
+func TestParseGroupEventText(t *testing.T) {
+	resolve := memberResolver(func(n string) EventMember {
+		if n == selfName {
+			return EventMember{Wxid: "wxid_self", NickName: n}
+		}
+		return EventMember{NickName: n}
+	})
+	self := EventMember{Wxid: "wxid_self", NickName: selfName}
+	zs, ls, ww := EventMember{NickName: "张三"}, EventMember{NickName: "李四"}, EventMember{NickName: "王五"}
+	tests := []struct {
+		text string
+		want GroupEvent
+	}{
+		{`"张三"邀请"李四、王五"加入了群聊`, &MemberJoined{Inviter: zs, Members: []EventMember{ls, ww}}},
+		{`你邀请"李四"加入了群聊  `, &MemberJoined{Inviter: self, Members: []EventMember{ls}}},
+		{`"张三"邀请你加入了群聊，群聊参与人还有：李四`, &MemberJoined{Inviter: zs, Members: []EventMember{self}}},
+		{`"李四"通过扫描"张三"分享的二维码加入群聊`, &MemberJoined{Inviter: zs, Members: []EventMember{ls}, ByQRCode: true}},
+		{`"李四"通过扫描你分享的二维码加入群聊`, &MemberJoined{Inviter: self, Members: []EventMember{ls}, ByQRCode: true}},
+		{`你将"李四"移出了群聊`, &MemberLeft{Operator: self, Members: []EventMember{ls}}},
+		{`你被"张三"移出群聊`, &MemberLeft{Operator: zs, Members: []EventMember{self}}},
+		{`"张三"修改群名为“新的群名”`, &RoomRenamed{Operator: zs, New: "新的群名"}},
+		{`"张三"已成为新群主`, &OwnerChanged{NewOwner: zs}},
+		{`"张三" 撤回了一条消息`, &MessageRevoked{By: zs}},
+		{`你撤回了一条消息`, &MessageRevoked{By: self}},
+		{`"李四"与群里其他人都不是朋友关系，请注意隐私安全`, nil},
+	}
+	for _, tt := range tests {
+		if got := parseGroupEventText(tt.text, resolve); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseGroupEventText(%q) = %+v, want %+v", tt.text, got, tt.want)
+		}
+	}
+}
+
+const (
+	inviteSysMsg = `45959390469@chatroom:
+<sysmsg type="sysmsgtemplate">
+	<sysmsgtemplate>
+		<content_template type="tmpl_type_profile">
+			<plain><![CDATA[]]></plain>
+			<template><![CDATA["$username$"邀请"$names$"加入了群聊]]></template>
+			<link_list>
+				<link name="username" type="link_profile">
+					<memberlist><member><username><![CDATA[wxid_zhangsan]]></username><nickname><![CDATA[张三]]></nickname></member></memberlist>
+				</link>
+				<link name="names" type="link_profile">
+					<memberlist>
+						<member><username><![CDATA[wxid_lisi]]></username><nickname><![CDATA[李四]]></nickname></member>
+						<member><username><![CDATA[wxid_wangwu]]></username><nickname><![CDATA[王五]]></nickname></member>
+					</memberlist>
+					<separator><![CDATA[、]]></separator>
+				</link>
+			</link_list>
+		</content_template>
+	</sysmsgtemplate>
+</sysmsg>`
+	revokeSysMsg       = `<sysmsg type="revokemsg"><revokemsg><session>45959390469@chatroom</session><msgid>1234567</msgid><newmsgid>8834937450581962231</newmsgid><replacemsg><![CDATA["张三" 撤回了一条消息]]></replacemsg></revokemsg></sysmsg>`
+	announcementSysMsg = `<sysmsg type="mmchatroombarannouncememt"><mmchatroombarannouncememt><content><![CDATA[本周六停水]]></content><ispin>1</ispin></mmchatroombarannouncememt></sysmsg>`
+)
+
+func TestGroupEventFromSysMsg(t *testing.T) {
+	resolve := memberResolver(func(n string) EventMember { return EventMember{NickName: n} })
+	tests := []struct {
+		name    string
+		content string
+		want    GroupEvent
+	}{
+		{"invite", inviteSysMsg, &MemberJoined{
+			Inviter: EventMember{Wxid: "wxid_zhangsan", NickName: "张三"},
+			Members: []EventMember{{Wxid: "wxid_lisi", NickName: "李四"}, {Wxid: "wxid_wangwu", NickName: "王五"}},
+		}},
+		{"revoke", revokeSysMsg, &MessageRevoked{MsgId: 8834937450581962231, By: EventMember{NickName: "张三"}}},
+		{"announcement", announcementSysMsg, &AnnouncementChanged{Content: "本周六停水"}},
+		{"pat", `<sysmsg type="pat"><pat></pat></sysmsg>`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm, err := parseSysMsg(tt.content)
+			if err != nil {
+				t.Fatalf("parseSysMsg() error = %v", err)
+			}
+			if got := groupEventFromSysMsg(sm, resolve); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("groupEventFromSysMsg() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_GroupEvent(t *testing.T) {
+	cli, srv := newOfflineClient(t)
+	if err := cli.Run(false); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	room := "45959390469@chatroom"
+	cli.cacheMember.CacheContactInfo(&ContactInfo{Wxid: room, NickName: "测试12"})
+	_ = srv.Push(&wcf.WxMsg{Id: 1, Type: uint32(MsgTypeSystem), IsGroup: true, Roomid: room, Sender: room, Content: `"张三"修改群名为“新的群名”`})
+	msg := recvMsg(t, cli)
+	renamed, ok := msg.GroupEvent.(*RoomRenamed)
+	if !ok || renamed.New != "新的群名" || renamed.Old != "测试12" || renamed.Operator.NickName != "张三" {
+		t.Fatalf("GroupEvent = %#v", msg.GroupEvent)
+	}
+
+	_ = srv.Push(&wcf.WxMsg{Id: 2, Type: uint32(MsgTypeRevoke), IsGroup: true, Roomid: room, Sender: "wxid_friend", Content: revokeSysMsg})
+	msg = recvMsg(t, cli)
+	if revoked, ok := msg.GroupEvent.(*MessageRevoked); !ok || revoked.MsgId != 8834937450581962231 {
+		t.Fatalf("GroupEvent = %#v", msg.GroupEvent)
+	}
+
+	_ = srv.Push(&wcf.WxMsg{Id: 3, Type: uint32(MsgTypeText), Sender: "wxid_friend", Content: `"张三"已成为新群主`})
+	if msg = recvMsg(t, cli); msg.GroupEvent != nil {
+		t.Errorf("text message GroupEvent = %#v, want nil", msg.GroupEvent)
+	}
+}
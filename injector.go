@@ -1,3 +1,5 @@
+//go:build windows
+
 // Package wcf_rpc_sdk
 // @Author Clover
 // @Data 2025/1/15 下午11:04:00
@@ -7,7 +9,7 @@ package wcf_rpc_sdk
 import (
 	"context"
 	"fmt"
-	"github.com/Clov614/logging"
+	"github.com/Clov614/wcf-rpc-sdk/internal/utils/logutil"
 	"io"
 	"net/http"
 	"os"
@@ -22,10 +24,6 @@ const funcDestroy = "WxDestroySDK"
 
 var gblDll *syscall.DLL
 
-func log(args ...interface{}) {
-	fmt.Println("\033[1;7;32m[Inj]\033[0m", time.Now().Format("20060102_150405"), args)
-}
-
 ///** 初始化. 加载动态库 */
 //func init() {
 //	log("Load dll:", libSdk)
@@ -37,12 +35,12 @@ func log(args ...interface{}) {
 //}
 
 /** 调用库接口 */
-func callFunc(funName string, title string, debug bool, port int) {
-	logging.Info(title)
+func callFunc(logger Logger, funName string, title string, debug bool, port int) error {
+	logger.Info(title)
 	// log("Find function:", fun_name, "in dll:", gbl_dll)
 	fun, err := gblDll.FindProc(funName)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	// log("Call function:", fun)
@@ -52,97 +50,104 @@ func callFunc(funName string, title string, debug bool, port int) {
 	}
 	ret, _e, errno := syscall.Syscall(fun.Addr(), dbgUintptr, 0, uintptr(port), 0)
 	if ret != 0 {
-		panic("Function " + fmt.Sprint(fun) + " run failed! return:" +
-			fmt.Sprint(ret) + ", err:" + fmt.Sprint(_e) + ", errno:" + fmt.Sprint(errno))
+		return fmt.Errorf("function %s run failed! return: %d, err: %d, errno: %v", funName, ret, _e, errno)
 	}
+	return nil
 }
 
 /** 监听并等待SIGINT信号 */
-func waitingSignal(ctx context.Context) {
+func waitingSignal(ctx context.Context, logger Logger) {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	logging.Info("Is running, press Ctrl+C to quit.")
+	defer signal.Stop(sigChan)
+	logger.Info("Is running, press Ctrl+C to quit.")
 	select {
 	case <-ctx.Done():
-		logging.Info("Context cancelled, exiting.")
+		logger.Info("Context cancelled, exiting.")
 	case <-sigChan:
-		logging.Info("Signal received, exiting.")
+		logger.Info("Signal received, exiting.")
 	}
-	logging.Info("Stopped!")
+	logger.Info("Stopped!")
 }
 
-func Inject(ctx context.Context, cancel context.CancelFunc, port int, debug bool, syncChan chan struct{}) {
-	logging.Warn("自动注入中...", map[string]interface{}{"hint": "请检查是否安装对应微信3.9.12.17版本，如未安装请前往地址下载&安装", "wechatSetUpUrl": "https://github.com/lich0821/WeChatFerry/releases/download/v39.4.3/WeChatSetup-3.9.12.17.exe"})
-	logging.Info("debug 模式状态", map[string]interface{}{"debug": debug})
+// Inject 注入 sdk 并启动微信，注入成功后向 syncChan 发送信号并阻塞至退出；注入失败时返回 error。logger 为 nil 时丢弃日志
+func Inject(ctx context.Context, cancel context.CancelFunc, port int, debug bool, syncChan chan struct{}, logger Logger) error {
+	logger = logutil.OrNop(logger)
+	logger.Warn(nil, "自动注入中...", map[string]interface{}{"hint": "请检查是否安装对应微信3.9.12.17版本，如未安装请前往地址下载&安装", "wechatSetUpUrl": "https://github.com/lich0821/WeChatFerry/releases/download/v39.4.3/WeChatSetup-3.9.12.17.exe"})
+	logger.Info("debug 模式状态", map[string]interface{}{"debug": debug})
 	// 加载调用库
-	log("Load dll:", libSdk)
+	logger.Debug("Load dll", map[string]interface{}{"dll": libSdk})
 	var err error
 	gblDll, err = syscall.LoadDLL(libSdk)
 	if err != nil {
-		logging.ErrorWithErr(err, "Failed to load dll", map[string]interface{}{"hint": "请检查目录下是否放置sdk.dll & spy.dll & spy_debug.dll"})
+		logger.Error(err, "Failed to load dll", map[string]interface{}{"hint": "请检查目录下是否放置sdk.dll & spy.dll & spy_debug.dll"})
 		// 尝试下载并重试
-		if !downloadAndRetry() {
-			logging.Fatal("inject failed!", -1000)
+		if err = downloadAndRetry(logger); err != nil {
+			return fmt.Errorf("inject failed: %w", err)
 		}
-		return
 	}
 
-	logging.Info("### Inject SDK into WeChat ###")
-	logging.Info(fmt.Sprintf("Set sdk port: %d, debug: %t", port, debug))
+	logger.Info("### Inject SDK into WeChat ###")
+	logger.Info(fmt.Sprintf("Set sdk port: %d, debug: %t", port, debug))
 
 	startAt := time.Now()
 	defer cancel() // 通知外层退出
 	for {
 		select {
 		case <-ctx.Done():
-			logging.Info("Injection process cancelled.")
-			return
+			logger.Info("Injection process cancelled.")
+			return nil
 		default:
-			if tryInject(debug, port) {
+			if tryInject(ctx, logger, debug, port) {
 				syncChan <- struct{}{} // 注入成功通知
-				logging.Info(fmt.Sprintf("SDK inject success. Time used: %f", time.Now().Sub(startAt).Seconds()))
-				waitingSignal(ctx)
-				callFunc(funcDestroy, "SDK destroy", debug, port)
+				logger.Info(fmt.Sprintf("SDK inject success. Time used: %f", time.Now().Sub(startAt).Seconds()))
+				waitingSignal(ctx, logger)
+				if err = callFunc(logger, funcDestroy, "SDK destroy", debug, port); err != nil {
+					logger.Error(err, "SDK destroy failed")
+				}
 				_ = gblDll.Release()
-				return
+				return nil
 			}
 		}
 	}
 }
 
 // 尝试注入
-func tryInject(debug bool, port int) (success bool) {
-	defer func() {
-		if r := recover(); r != nil { // 注入失败时反复重试
-			logging.Error(fmt.Sprintf("Get panic: %v, Wait for retry...", r))
-			time.Sleep(3 * time.Second)
-			success = false
+func tryInject(ctx context.Context, logger Logger, debug bool, port int) (success bool) {
+	if err := callFunc(logger, funcInject, "Inject SDK...", debug, port); err != nil { // 注入失败时反复重试
+		logger.Error(err, "Inject failed, Wait for retry...")
+		select {
+		case <-ctx.Done():
+		case <-time.After(3 * time.Second):
 		}
-	}()
-	callFunc(funcInject, "Inject SDK...", debug, port)
+		return false
+	}
 	return true
 }
 
-// 下载所需 DLL 文件并重试注入
-func downloadAndRetry() bool {
+// 下载所需 DLL 文件并重新加载
+func downloadAndRetry(logger Logger) error {
 	dlls := []string{"sdk.dll", "spy.dll", "spy_debug.dll", "DISCLAIMER.md"}
 	// 使用 raw.githubusercontent.com 的地址
 	baseUrl := "https://raw.githubusercontent.com/Clov614/wcf-rpc-sdk/main/sources/sdk/3.12.17/"
 
 	for _, dll := range dlls {
 		url := baseUrl + dll
-		logging.Info(fmt.Sprintf("Downloading %s from %s", dll, url))
+		logger.Info(fmt.Sprintf("Downloading %s from %s", dll, url))
 		err := downloadFile(dll, url)
 		if err != nil {
-			logging.ErrorWithErr(err, fmt.Sprintf("Failed to download %s", dll), nil)
-			return false
+			return fmt.Errorf("failed to download %s: %w", dll, err)
 		}
-		logging.Info(fmt.Sprintf("Successfully downloaded %s", dll))
+		logger.Info(fmt.Sprintf("Successfully downloaded %s", dll))
 	}
 
-	// 重试注入
-	logging.Fatal("已远程拉取.dll文件，请检查dll是否存在，重启程序", 0001)
-	return false
+	// 重新加载
+	var err error
+	gblDll, err = syscall.LoadDLL(libSdk)
+	if err != nil {
+		return fmt.Errorf("已远程拉取.dll文件，但加载失败，请检查dll是否存在: %w", err)
+	}
+	return nil
 }
 
 // 下载文件的辅助函数
@@ -0,0 +1,23 @@
+//go:build !windows
+
+// Package wcf_rpc_sdk
+// @Author Clover
+// @Data 2026/10/16 下午2:10:00
+// @Desc 非 windows 平台的注入器占位实现
+package wcf_rpc_sdk
+
+import (
+	"context"
+	"github.com/Clov614/wcf-rpc-sdk/internal/utils/logutil"
+	"runtime"
+)
+
+// Inject 非 windows 平台无法加载 sdk.dll，仅提示并放行，继续连接远端 RPC 服务
+func Inject(ctx context.Context, cancel context.CancelFunc, port int, debug bool, syncChan chan struct{}, logger Logger) error {
+	logutil.OrNop(logger).Warn(nil, "当前平台不支持自动注入，请确认 RPC 服务已在远端启动", map[string]interface{}{"os": runtime.GOOS, "port": port, "debug": debug})
+	select {
+	case <-ctx.Done():
+	case syncChan <- struct{}{}:
+	}
+	return nil
+}
@@ -10,6 +10,7 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"github.com/Clov614/wcf-rpc-sdk/internal/utils/logutil"
 	"io"
 	"net/http"
 	"os"
@@ -190,7 +191,8 @@ func decodeDatFileInternal(datFilePath string, writer io.Writer) error {
 // DecodeDatFile 解码微信 .dat 文件为图片, 并保存到指定目录
 // datFilePath: .dat 文件路径
 // outputDir:  输出目录
-func DecodeDatFile(datFilePath, outputDir string) error {
+// logger:     日志输出，可为 nil
+func DecodeDatFile(datFilePath, outputDir string, logger logutil.Logger) error {
 	// 检查输出目录是否存在，不存在则创建
 	if _, err := os.Stat(outputDir); os.IsNotExist(err) {
 		if err := os.MkdirAll(outputDir, 0755); err != nil {
@@ -239,16 +241,16 @@ func DecodeDatFile(datFilePath, outputDir string) error {
 		return fmt.Errorf("DecodeDatFile: decodeDatFileInternal: %w", err)
 	}
 
-	fmt.Println("DecodeDatFile: output file：", distFile.Name()) // 保留输出信息
+	logutil.OrNop(logger).Debug("DecodeDatFile: decode file success", map[string]interface{}{"output": distFile.Name()})
 	return nil
 }
 
 // DecodeDatFileToBytes 解码微信 .dat 文件为图片, 并返回字节数组
 // datFilePath: .dat 文件路径
-func DecodeDatFileToBytes(datFilePath string) ([]byte, error) {
+// logger:     日志输出，可为 nil
+func DecodeDatFileToBytes(datFilePath string, logger logutil.Logger) ([]byte, error) {
 	// 使用新的检查函数，设置重试次数为 5
 	if err := checkDatFileExists(datFilePath, 5); err != nil {
-		//logging.ErrorWithErr(err, "DecodeDatFile .dat was not exist") // 可以保留日志记录
 		return nil, fmt.Errorf("DecodeDatFileToBytes: %w", err)
 	}
 
@@ -275,8 +277,8 @@ func DecodeDatFileToBytes(datFilePath string) ([]byte, error) {
 		return nil, fmt.Errorf("DecodeDatFileToBytes: decodeDatFileInternal: %w", err)
 	}
 
-	fmt.Println("DecodeDatFileToBytes: decode file success") // 保留输出信息
-	return decodedData.Bytes(), nil                          // 返回解码后的字节数组
+	logutil.OrNop(logger).Debug("DecodeDatFileToBytes: decode file success", map[string]interface{}{"path": datFilePath})
+	return decodedData.Bytes(), nil // 返回解码后的字节数组
 }
 
 func findDecodeByte(bts []byte) (byte, string, error) {
@@ -5,6 +5,7 @@
 package imgutil
 
 import (
+	"github.com/Clov614/wcf-rpc-sdk/internal/utils/logutil"
 	"os"
 	"path/filepath"
 	"testing"
@@ -108,7 +109,7 @@ func TestDecodeDatFile(t *testing.T) {
 			}
 
 			// 3. 调用 DecodeDatFile 函数进行解码
-			err = DecodeDatFile(datFilePath, outputDir)
+			err = DecodeDatFile(datFilePath, outputDir, logutil.Nop{})
 			if err != nil {
 				t.Fatalf("[%s] DecodeDatFile failed: %v", tc.name, err)
 			}
@@ -209,7 +210,7 @@ func TestDecodeDatFileToBytes(t *testing.T) {
 			}
 
 			// 3. 调用 DecodeDatFileToBytes 函数进行解码
-			decodedDataBytes, err := DecodeDatFileToBytes(datFilePath) // 调用新的 DecodeDatFileToBytes 函数
+			decodedDataBytes, err := DecodeDatFileToBytes(datFilePath, logutil.Nop{}) // 调用新的 DecodeDatFileToBytes 函数
 			if err != nil {
 				t.Fatalf("[%s] DecodeDatFileToBytes failed: %v", tc.name, err)
 			}
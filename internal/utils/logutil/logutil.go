@@ -0,0 +1,66 @@
+// Package logutil
+// @Author Clover
+// @Data 2026/10/16 下午9:20:00
+// @Desc SDK 内部共用的日志接口
+package logutil
+
+import "sync/atomic"
+
+// Logger 日志接口，fields 为附加字段
+type Logger interface {
+	Debug(msg string, fields ...map[string]interface{})
+	Info(msg string, fields ...map[string]interface{})
+	Warn(err error, msg string, fields ...map[string]interface{})  // err 可为 nil
+	Error(err error, msg string, fields ...map[string]interface{}) // err 可为 nil
+}
+
+// Nop 丢弃所有日志
+type Nop struct{}
+
+func (Nop) Debug(string, ...map[string]interface{})        {}
+func (Nop) Info(string, ...map[string]interface{})         {}
+func (Nop) Warn(error, string, ...map[string]interface{})  {}
+func (Nop) Error(error, string, ...map[string]interface{}) {}
+
+// OrNop logger 为 nil 时返回 Nop
+func OrNop(logger Logger) Logger {
+	if logger == nil {
+		return Nop{}
+	}
+	return logger
+}
+
+// Leveled 按开关过滤 Debug 日志，只作用于当前实例，不修改全局日志级别
+type Leveled struct {
+	Logger
+	debug atomic.Bool
+}
+
+// NewLeveled <下层日志> <是否输出 Debug>
+func NewLeveled(logger Logger, debug bool) *Leveled {
+	l := &Leveled{Logger: OrNop(logger)}
+	l.debug.Store(debug)
+	return l
+}
+
+// SetDebug 开关 Debug 日志
+func (l *Leveled) SetDebug(debug bool) {
+	l.debug.Store(debug)
+}
+
+func (l *Leveled) Debug(msg string, fields ...map[string]interface{}) {
+	if l.debug.Load() {
+		l.Logger.Debug(msg, fields...)
+	}
+}
+
+// MergeFields 合并多个字段表，后者覆盖前者
+func MergeFields(fields ...map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{})
+	for _, field := range fields {
+		for k, v := range field {
+			merged[k] = v
+		}
+	}
+	return merged
+}
@@ -0,0 +1,59 @@
+// Package wcf
+// @Author Clover
+// @Data 2026/10/16 下午3:20:00
+// @Desc RPC 调用的错误类型
+package wcf
+
+import (
+	"errors"
+	"fmt"
+	"go.nanomsg.org/mangos/v3"
+)
+
+var (
+	ErrTransport   = errors.New("wcf: transport error")                  // socket 收发失败（连接断开、已关闭等）
+	ErrTimeout     = errors.New("wcf: rpc timeout")                      // 收发超时
+	ErrDecode      = errors.New("wcf: decode response error")            // 应答无法解析
+	ErrMismatch    = errors.New("wcf: response func mismatch")           // 应答与请求的 Func 不一致
+	ErrUnsupported = errors.New("wcf: function not supported by server") // 服务端版本不支持该接口
+)
+
+// ErrStatus RPC 正常应答，但返回了表示失败的状态码
+type ErrStatus struct {
+	Func Functions
+	Code int32
+}
+
+func (e *ErrStatus) Error() string {
+	return fmt.Sprintf("wcf: %s failed with status %d", e.Func, e.Code)
+}
+
+// successStatus 各个返回状态码的接口所约定的成功值
+var successStatus = map[Functions]int32{
+	Functions_FUNC_SEND_TXT:         0,
+	Functions_FUNC_SEND_IMG:         0,
+	Functions_FUNC_SEND_FILE:        0,
+	Functions_FUNC_SEND_XML:         0,
+	Functions_FUNC_SEND_EMOTION:     0,
+	Functions_FUNC_SEND_RICH_TXT:    1,
+	Functions_FUNC_SEND_PAT_MSG:     1,
+	Functions_FUNC_FORWARD_MSG:      1,
+	Functions_FUNC_ENABLE_RECV_TXT:  0,
+	Functions_FUNC_DISABLE_RECV_TXT: 0,
+	Functions_FUNC_ACCEPT_FRIEND:    1,
+	Functions_FUNC_RECV_TRANSFER:    1,
+	Functions_FUNC_REFRESH_PYQ:      1,
+	Functions_FUNC_DOWNLOAD_ATTACH:  0,
+	Functions_FUNC_REVOKE_MSG:       1,
+	Functions_FUNC_ADD_ROOM_MEMBERS: 1,
+	Functions_FUNC_DEL_ROOM_MEMBERS: 1,
+	Functions_FUNC_INV_ROOM_MEMBERS: 1,
+}
+
+// wrapSocketErr 将 mangos 的错误归类为 ErrTimeout 或 ErrTransport
+func wrapSocketErr(err error) error {
+	if errors.Is(err, mangos.ErrRecvTimeout) || errors.Is(err, mangos.ErrSendTimeout) {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+	return fmt.Errorf("%w: %w", ErrTransport, err)
+}
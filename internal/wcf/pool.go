@@ -0,0 +1,287 @@
+// Package wcf
+// @Author Clover
+// @Data 2026/10/16 下午10:05:00
+// @Desc 消息处理协程池
+package wcf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	DefaultPoolWorkers   = 8    // 默认消息处理协程数
+	DefaultPoolQueueSize = 1024 // 默认待处理消息队列长度
+)
+
+var ErrPoolClosed = errors.New("wcf: message pool closed")
+
+// OverflowPolicy 待处理队列已满时的处理策略
+type OverflowPolicy uint8
+
+const (
+	OverflowBlock      OverflowPolicy = iota // 阻塞接收，直到队列有空位
+	OverflowDropOldest                       // 丢弃队列中最早的消息
+	OverflowDropNewest                       // 丢弃新到达的消息
+)
+
+var overflowPolicyNames = map[OverflowPolicy]string{
+	OverflowBlock:      "Block",
+	OverflowDropOldest: "DropOldest",
+	OverflowDropNewest: "DropNewest",
+}
+
+func (p OverflowPolicy) String() string {
+	if name, ok := overflowPolicyNames[p]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// PoolConfig 消息处理协程池配置
+type PoolConfig struct {
+	Workers   int            // 处理协程数
+	QueueSize int            // 待处理队列长度，Ordered 时平分给各协程
+	Overflow  OverflowPolicy // 队列已满时的策略
+	Ordered   bool           // 同一会话（群 RoomId 或私聊对方）的消息固定由一个协程按到达顺序处理
+}
+
+// DefaultPoolConfig 默认配置：8 个协程，队列 1024，满时阻塞
+func DefaultPoolConfig() PoolConfig {
+	return PoolConfig{Workers: DefaultPoolWorkers, QueueSize: DefaultPoolQueueSize, Overflow: OverflowBlock}
+}
+
+// Validate 校验配置
+func (cfg PoolConfig) Validate() error {
+	if cfg.Workers < 1 {
+		return fmt.Errorf("wcf: pool workers must be positive, got %d", cfg.Workers)
+	}
+	if cfg.QueueSize < 1 {
+		return fmt.Errorf("wcf: pool queue size must be positive, got %d", cfg.QueueSize)
+	}
+	if _, ok := overflowPolicyNames[cfg.Overflow]; !ok {
+		return fmt.Errorf("wcf: unknown overflow policy %d", cfg.Overflow)
+	}
+	return nil
+}
+
+// PoolStats 协程池运行指标
+type PoolStats struct {
+	Workers    int    `json:"workers"`      // 处理协程数
+	Busy       int    `json:"busy"`         // 正在处理消息的协程数
+	QueueDepth int    `json:"queue_depth"`  // 当前排队的消息数
+	QueueCap   int    `json:"queue_cap"`    // 队列长度
+	Received   uint64 `json:"received"`     // 累计收到的消息数
+	Enqueued   uint64 `json:"enqueued"`     // 累计入队的消息数
+	Dropped    uint64 `json:"dropped"`      // 累计因队列已满被丢弃的消息数
+	Handled    uint64 `json:"handled"`      // 累计处理完成的消息数
+	Failed     uint64 `json:"failed"`       // 累计处理返回 error 的消息数
+	OutOfOrder uint64 `json:"out_of_order"` // 累计检测到时间戳早于同会话上一条的消息数，仅 Ordered
+	Duplicated uint64 `json:"duplicated"`   // 累计与同会话上一条重复而被跳过的消息数，仅 Ordered
+}
+
+type poolTask struct {
+	msg *WxMsg
+	f   MsgHandler
+}
+
+// convKey 消息所属会话：群聊为 RoomId，私聊为对方 wxid（自己发出的消息 Roomid 为接收方）
+func convKey(msg *WxMsg) string {
+	if msg.GetRoomid() != "" {
+		return msg.GetRoomid()
+	}
+	return msg.GetSender()
+}
+
+// msgSeq 会话中上一条消息的位置
+type msgSeq struct {
+	ts uint32
+	id uint64
+}
+
+// msgPool 固定数量的协程消费有界队列，跨越重连保持运行，Close 时停止
+// Ordered 时每个协程拥有独立队列，消息按会话哈希分配，保证会话内先进先出
+type msgPool struct {
+	cfg       PoolConfig
+	queues    []chan poolTask
+	done      chan struct{}
+	client    *Client
+	startOnce sync.Once
+	stopOnce  sync.Once
+
+	busy     atomic.Int64
+	received atomic.Uint64
+	enqueued atomic.Uint64
+	dropped  atomic.Uint64
+	handled  atomic.Uint64
+	failed   atomic.Uint64
+	disorder atomic.Uint64
+	dup      atomic.Uint64
+}
+
+func newMsgPool(cfg PoolConfig, client *Client) *msgPool {
+	p := &msgPool{
+		cfg:    cfg,
+		done:   make(chan struct{}),
+		client: client,
+	}
+	if !cfg.Ordered {
+		p.queues = []chan poolTask{make(chan poolTask, cfg.QueueSize)}
+		return p
+	}
+	size := (cfg.QueueSize + cfg.Workers - 1) / cfg.Workers
+	p.queues = make([]chan poolTask, cfg.Workers)
+	for i := range p.queues {
+		p.queues[i] = make(chan poolTask, size)
+	}
+	return p
+}
+
+func (p *msgPool) start() {
+	p.startOnce.Do(func() {
+		for i := 0; i < p.cfg.Workers; i++ {
+			go p.work(p.queues[i%len(p.queues)])
+		}
+	})
+}
+
+func (p *msgPool) stop() {
+	p.stopOnce.Do(func() {
+		close(p.done)
+	})
+}
+
+func (p *msgPool) work(queue chan poolTask) {
+	var last map[string]msgSeq // 各会话上一条消息，仅由本协程访问
+	if p.cfg.Ordered {
+		last = make(map[string]msgSeq)
+	}
+	for {
+		select {
+		case <-p.done:
+			return
+		case t := <-queue:
+			if last != nil && !p.checkSeq(last, t.msg) {
+				continue
+			}
+			p.run(t)
+		}
+	}
+}
+
+// checkSeq 检测会话内的乱序与重复，重复消息返回 false
+func (p *msgPool) checkSeq(last map[string]msgSeq, msg *WxMsg) bool {
+	key := convKey(msg)
+	cur := msgSeq{ts: msg.GetTs(), id: msg.GetId()}
+	prev, ok := last[key]
+	if ok && cur.id == prev.id {
+		p.dup.Add(1)
+		p.client.logger.Debug("wcf: duplicated message skipped", map[string]interface{}{"conv": key, "id": cur.id})
+		return false
+	}
+	if ok && cur.ts < prev.ts {
+		p.disorder.Add(1)
+		p.client.logger.Warn(nil, "wcf: message out of order", map[string]interface{}{
+			"conv": key, "id": cur.id, "ts": cur.ts, "prevId": prev.id, "prevTs": prev.ts,
+		})
+		return true // 仍按到达顺序投递，不回退会话位置
+	}
+	last[key] = cur
+	return true
+}
+
+func (p *msgPool) run(t poolTask) {
+	p.busy.Add(1)
+	defer p.busy.Add(-1)
+	if err := t.f(t.msg); err != nil {
+		p.failed.Add(1)
+		p.client.logger.Warn(err, "wcf: onMsg handler", map[string]interface{}{"id": t.msg.GetId()})
+	}
+	p.handled.Add(1)
+}
+
+// submit 按溢出策略投递消息，仅 OverflowBlock 会阻塞
+func (p *msgPool) submit(ctx context.Context, t poolTask) error {
+	select {
+	case <-p.done:
+		return ErrPoolClosed
+	default:
+	}
+	defer p.received.Add(1) // 入队或丢弃后再计数，Received 稳定时其余指标已更新
+	queue := p.queueOf(t.msg)
+	switch p.cfg.Overflow {
+	case OverflowDropNewest:
+		select {
+		case queue <- t:
+		default:
+			p.drop(t)
+			return nil
+		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case queue <- t:
+				p.enqueued.Add(1)
+				return nil
+			default:
+			}
+			select {
+			case old := <-queue:
+				p.drop(old)
+			default: // 刚被协程取走，重试投递
+			}
+		}
+	default:
+		select {
+		case queue <- t:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-p.done:
+			return ErrPoolClosed
+		}
+	}
+	p.enqueued.Add(1)
+	return nil
+}
+
+// queueOf Ordered 时按会话哈希选择队列
+func (p *msgPool) queueOf(msg *WxMsg) chan poolTask {
+	if len(p.queues) == 1 {
+		return p.queues[0]
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(convKey(msg)))
+	return p.queues[h.Sum32()%uint32(len(p.queues))]
+}
+
+func (p *msgPool) drop(t poolTask) {
+	dropped := p.dropped.Add(1)
+	p.client.logger.Debug("wcf: message dropped, pool queue is full", map[string]interface{}{
+		"id": t.msg.GetId(), "policy": p.cfg.Overflow.String(), "dropped": dropped,
+	})
+}
+
+func (p *msgPool) stats() PoolStats {
+	var depth, capacity int
+	for _, queue := range p.queues {
+		depth += len(queue)
+		capacity += cap(queue)
+	}
+	return PoolStats{
+		Workers:    p.cfg.Workers,
+		Busy:       int(p.busy.Load()),
+		QueueDepth: depth,
+		QueueCap:   capacity,
+		Received:   p.received.Load(),
+		Enqueued:   p.enqueued.Load(),
+		Dropped:    p.dropped.Load(),
+		Handled:    p.handled.Load(),
+		Failed:     p.failed.Load(),
+		OutOfOrder: p.disorder.Load(),
+		Duplicated: p.dup.Load(),
+	}
+}
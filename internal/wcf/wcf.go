@@ -2,25 +2,43 @@ package wcf
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"github.com/Clov614/logging"
+	"github.com/Clov614/wcf-rpc-sdk/internal/utils/logutil"
 	"go.nanomsg.org/mangos/v3"
 	"go.nanomsg.org/mangos/v3/protocol"
 	"go.nanomsg.org/mangos/v3/protocol/pair1"
 	_ "go.nanomsg.org/mangos/v3/transport/all"
 	"google.golang.org/protobuf/proto"
+	"net"
+	"net/url"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// DefaultTimeout 单次 RPC 调用的默认超时时间
+const DefaultTimeout = 30 * time.Second
+
+// pollInterval 阻塞收发时检查 ctx 的间隔
+const pollInterval = 50 * time.Millisecond
+
 type Client struct {
 	add                string
 	socket             protocol.Socket
-	RecvTxt            bool
+	RecvTxt            atomic.Bool // 是否已开启接收消息，关闭后 OnMSG 退出
 	ContactsMap        []map[string]string
 	MessageCallbackUrl string
-	mu                 sync.Mutex
+	callSem            chan struct{} // 容量为 1，保证一次请求-应答交换的原子性，等待时可被 ctx 取消
+	sockMu             sync.Mutex    // 保护 socket 字段，使 Close、Redial 不必等待进行中的调用
+	timeout            atomic.Int64  // 未设置 deadline 的调用使用的默认超时（纳秒），<=0 时不限制
+	stale              int           // 已放弃等待、但尚未读走的应答数量，需持有 callSem
+	logger             logutil.Logger
+	poolMu             sync.Mutex
+	poolCfg            PoolConfig // 消息处理协程池配置
+	pool               *msgPool   // 首次接收消息时创建
 }
 
 func (c *Client) conn() error {
@@ -30,313 +48,503 @@ func (c *Client) conn() error {
 	}
 	err = socket.Dial(c.add)
 	if err != nil {
-		return err
+		_ = socket.Close()
+		return wrapSocketErr(err)
 	}
+	c.sockMu.Lock()
 	c.socket = socket
+	c.sockMu.Unlock()
 	return err
 }
 
-func (c *Client) send(data []byte) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	return c.socket.Send(data)
+func (c *Client) sock() protocol.Socket {
+	c.sockMu.Lock()
+	defer c.sockMu.Unlock()
+	return c.socket
 }
 
-func (c *Client) Recv() (*Response, error) {
-	msg := &Response{}
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	recv, err := c.socket.Recv()
-	if err != nil {
-		return msg, err
+// Redial 关闭并重新拨号命令端口，进行中的调用会立即返回 ErrTransport，未读的应答被丢弃
+func (c *Client) Redial() error {
+	if socket := c.sock(); socket != nil {
+		_ = socket.Close()
 	}
-	err = proto.Unmarshal(recv, msg)
-	return msg, err
+	_ = c.lock(context.Background())
+	defer c.unlock()
+	c.logger.Debug("wcf: redial", map[string]interface{}{"addr": c.add, "stale": c.stale})
+	c.stale = 0
+	return c.conn()
 }
 
-// Close 退出
-func (c *Client) Close() error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	return c.socket.Close()
+// SetLogger 设置日志输出，需在收发消息前调用；nil 时丢弃日志
+func (c *Client) SetLogger(logger logutil.Logger) {
+	c.logger = logutil.OrNop(logger)
 }
 
-// IsLogin 查看是否登录
-func (c *Client) IsLogin() bool {
-	err := c.send(genFunReq(Functions_FUNC_IS_LOGIN).build())
-	if err != nil {
-		logging.ErrorWithErr(err, "internal is_login err")
+// SetPoolConfig 设置消息处理协程池，需在 OnMSG 前调用
+func (c *Client) SetPoolConfig(cfg PoolConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	c.poolMu.Lock()
+	defer c.poolMu.Unlock()
+	if c.pool != nil {
+		return errors.New("wcf: message pool already started")
+	}
+	c.poolCfg = cfg
+	return nil
+}
+
+// PoolStats 消息处理协程池运行指标
+func (c *Client) PoolStats() PoolStats {
+	c.poolMu.Lock()
+	defer c.poolMu.Unlock()
+	if c.pool == nil {
+		return PoolStats{Workers: c.poolCfg.Workers, QueueCap: c.poolCfg.QueueSize}
+	}
+	return c.pool.stats()
+}
+
+func (c *Client) msgPool() *msgPool {
+	c.poolMu.Lock()
+	defer c.poolMu.Unlock()
+	if c.pool == nil {
+		c.pool = newMsgPool(c.poolCfg, c)
+		c.pool.start()
 	}
-	recv, err := c.Recv()
+	return c.pool
+}
+
+// SetTimeout 设置默认超时，<=0 时不限制（仍受 ctx 控制）
+func (c *Client) SetTimeout(timeout time.Duration) {
+	c.timeout.Store(int64(timeout))
+}
+
+// withTimeout ctx 未设置 deadline 时附加默认超时
+func (c *Client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := time.Duration(c.timeout.Load())
+	if _, ok := ctx.Deadline(); ok || timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// lock 取得请求-应答交换的执行权，排队期间 ctx 结束时返回 ctx 的错误
+func (c *Client) lock(ctx context.Context) error {
+	select {
+	case c.callSem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctxErr(ctx.Err())
+	}
+}
+
+func (c *Client) unlock() {
+	<-c.callSem
+}
+
+// call 一次完整的请求-应答交换，持锁期间完成发送与读取，避免并发调用读到彼此的应答
+func (c *Client) call(ctx context.Context, req *cmdMSG) (*Response, error) {
+	data, err := req.build()
 	if err != nil {
-		logging.ErrorWithErr(err, "internal is_login err")
+		return nil, err
 	}
-	if recv.GetStatus() == 1 {
-		return true
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	if err = c.lock(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", req.Func, err)
+	}
+	defer c.unlock()
+	if err = c.send(ctx, data); err != nil {
+		return nil, fmt.Errorf("%s: %w", req.Func, err)
+	}
+	for {
+		recv, err := c.recv(ctx)
+		if err != nil {
+			c.stale++ // 放弃的应答稍后仍会到达，下次调用时丢弃
+			return nil, fmt.Errorf("%s: %w", req.Func, err)
+		}
+		if c.stale > 0 { // 丢弃之前被放弃的调用的应答
+			c.stale--
+			c.logger.Debug("wcf: drop stale response", map[string]interface{}{"func": req.Func.String(), "stale": c.stale})
+			continue
+		}
+		resp := &Response{}
+		if err = proto.Unmarshal(recv, resp); err != nil {
+			return nil, fmt.Errorf("%s: %w: %w", req.Func, ErrDecode, err)
+		}
+		if resp.Func != req.Func { // 服务端对本次请求的应答 Func 有误，只有超时或放弃的调用才计入 stale
+			return nil, fmt.Errorf("%s: %w: got %s", req.Func, ErrMismatch, resp.Func)
+		}
+		return resp, nil
 	}
-	return false
 }
 
-// GetSelfWXID 获取登录的id
-func (c *Client) GetSelfWXID() string {
-	err := c.send(genFunReq(Functions_FUNC_GET_SELF_WXID).build())
+// callStatus 发送请求并校验应答中的状态码，失败时返回 *ErrStatus
+func (c *Client) callStatus(ctx context.Context, req *cmdMSG) (int32, error) {
+	recv, err := c.call(ctx, req)
 	if err != nil {
-		logging.ErrorWithErr(err, "internal is_login err")
+		return 0, err
+	}
+	status := recv.GetStatus()
+	if want, ok := successStatus[req.Func]; ok && status != want {
+		return status, &ErrStatus{Func: req.Func, Code: status}
+	}
+	return status, nil
+}
+
+// send 发送请求，需持有 callSem
+func (c *Client) send(ctx context.Context, data []byte) error {
+	if err := ctx.Err(); err != nil {
+		return ctxErr(err)
+	}
+	var wait time.Duration // 0 表示一直阻塞
+	if deadline, ok := ctx.Deadline(); ok {
+		if wait = time.Until(deadline); wait <= 0 { // mangos 中 <=0 表示不超时
+			return ctxErr(context.DeadlineExceeded)
+		}
+	}
+	socket := c.sock()
+	_ = socket.SetOption(mangos.OptionSendDeadline, wait)
+	if err := socket.Send(data); err != nil {
+		return wrapSocketErr(err)
+	}
+	return nil
+}
+
+// recv 分片阻塞读取以便及时响应 ctx，需持有 callSem
+func (c *Client) recv(ctx context.Context) ([]byte, error) {
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, ctxErr(err)
+		}
+		wait := pollInterval
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			if wait = time.Until(deadline); wait <= 0 { // mangos 中 <=0 表示不超时
+				return nil, ctxErr(context.DeadlineExceeded)
+			}
+		}
+		socket := c.sock()
+		_ = socket.SetOption(mangos.OptionRecvDeadline, wait)
+		recv, err := socket.Recv()
+		if errors.Is(err, mangos.ErrRecvTimeout) {
+			continue
+		}
+		if err != nil {
+			return nil, wrapSocketErr(err)
+		}
+		return recv, nil
+	}
+}
+
+// ctxErr 将 ctx 超时归类为 ErrTimeout
+func ctxErr(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+	return err
+}
+
+// Close 退出，进行中的调用会立即返回，协程池停止处理剩余消息
+func (c *Client) Close() error {
+	c.poolMu.Lock()
+	if c.pool != nil {
+		c.pool.stop()
 	}
-	recv, err := c.Recv()
+	c.poolMu.Unlock()
+	return c.sock().Close()
+}
+
+// IsLogin 查看是否登录
+func (c *Client) IsLogin() (bool, error) {
+	return c.IsLoginCtx(context.Background())
+}
+
+// IsLoginCtx 同 IsLogin，支持 ctx 取消与超时
+func (c *Client) IsLoginCtx(ctx context.Context) (bool, error) {
+	recv, err := c.call(ctx, genFunReq(Functions_FUNC_IS_LOGIN))
 	if err != nil {
-		logging.ErrorWithErr(err, "internal get self_WXID err")
+		return false, err
 	}
-	return recv.GetStr()
+	return recv.GetStatus() == 1, nil
 }
 
-// GetMsgTypes 获取消息类型
-func (c *Client) GetMsgTypes() map[int32]string {
-	err := c.send(genFunReq(Functions_FUNC_GET_MSG_TYPES).build())
+// GetSelfWXID 获取登录的id
+func (c *Client) GetSelfWXID() (string, error) {
+	return c.GetSelfWXIDCtx(context.Background())
+}
+
+// GetSelfWXIDCtx 同 GetSelfWXID，支持 ctx 取消与超时
+func (c *Client) GetSelfWXIDCtx(ctx context.Context) (string, error) {
+	recv, err := c.call(ctx, genFunReq(Functions_FUNC_GET_SELF_WXID))
 	if err != nil {
-		logging.ErrorWithErr(err, "internal GetMsgTypes err")
+		return "", err
 	}
-	recv, err := c.Recv()
+	return recv.GetStr(), nil
+}
+
+// GetMsgTypes 获取消息类型
+func (c *Client) GetMsgTypes() (map[int32]string, error) {
+	return c.GetMsgTypesCtx(context.Background())
+}
+
+// GetMsgTypesCtx 同 GetMsgTypes，支持 ctx 取消与超时
+func (c *Client) GetMsgTypesCtx(ctx context.Context) (map[int32]string, error) {
+	recv, err := c.call(ctx, genFunReq(Functions_FUNC_GET_MSG_TYPES))
 	if err != nil {
-		logging.ErrorWithErr(err, "internal GetMsgTypes err")
+		return nil, err
 	}
-	return recv.GetTypes().GetTypes()
+	return recv.GetTypes().GetTypes(), nil
 }
 
 // GetContacts 获取通讯录
-func (c *Client) GetContacts() []*RpcContact {
-	err := c.send(genFunReq(Functions_FUNC_GET_CONTACTS).build())
+func (c *Client) GetContacts() ([]*RpcContact, error) {
+	return c.GetContactsCtx(context.Background())
+}
+
+// GetContactsCtx 同 GetContacts，支持 ctx 取消与超时
+func (c *Client) GetContactsCtx(ctx context.Context) ([]*RpcContact, error) {
+	recv, err := c.call(ctx, genFunReq(Functions_FUNC_GET_CONTACTS))
 	if err != nil {
-		logging.ErrorWithErr(err, "internal GetContacts err")
+		return nil, err
+	}
+	return recv.GetContacts().GetContacts(), nil
+}
+
+// GetContactInfo 通过 wxid 查询单个联系人，不存在时返回 nil
+func (c *Client) GetContactInfo(wxid string) (*RpcContact, error) {
+	return c.GetContactInfoCtx(context.Background(), wxid)
+}
+
+// GetContactInfoCtx 同 GetContactInfo，支持 ctx 取消与超时；旧版本服务端不返回联系人列表时为 ErrUnsupported
+func (c *Client) GetContactInfoCtx(ctx context.Context, wxid string) (*RpcContact, error) {
+	req := genFunReq(Functions_FUNC_GET_CONTACT_INFO)
+	req.Msg = &Request_Str{
+		Str: wxid,
 	}
-	recv, err := c.Recv()
+	recv, err := c.call(ctx, req)
 	if err != nil {
-		logging.ErrorWithErr(err, "internal GetContacts err")
+		return nil, err
 	}
-	return recv.GetContacts().GetContacts()
+	if recv.GetContacts() == nil {
+		return nil, fmt.Errorf("%s: %w", req.Func, ErrUnsupported)
+	}
+	for _, contact := range recv.GetContacts().GetContacts() {
+		if contact.GetWxid() == wxid {
+			return contact, nil
+		}
+	}
+	return nil, nil
 }
 
 // GetDBNames 获取数据库名
-func (c *Client) GetDBNames() []string {
-	err := c.send(genFunReq(Functions_FUNC_GET_DB_NAMES).build())
-	if err != nil {
-		logging.ErrorWithErr(err, "internal GetDBNames err")
-	}
-	recv, err := c.Recv()
+func (c *Client) GetDBNames() ([]string, error) {
+	return c.GetDBNamesCtx(context.Background())
+}
+
+// GetDBNamesCtx 同 GetDBNames，支持 ctx 取消与超时
+func (c *Client) GetDBNamesCtx(ctx context.Context) ([]string, error) {
+	recv, err := c.call(ctx, genFunReq(Functions_FUNC_GET_DB_NAMES))
 	if err != nil {
-		logging.ErrorWithErr(err, "internal GetDBNames err")
+		return nil, err
 	}
-	return recv.GetDbs().Names
+	return recv.GetDbs().GetNames(), nil
 }
 
 // GetDBTables 获取表
-func (c *Client) GetDBTables(tab string) []*DbTable {
+func (c *Client) GetDBTables(tab string) ([]*DbTable, error) {
+	return c.GetDBTablesCtx(context.Background(), tab)
+}
+
+// GetDBTablesCtx 同 GetDBTables，支持 ctx 取消与超时
+func (c *Client) GetDBTablesCtx(ctx context.Context, tab string) ([]*DbTable, error) {
 	req := genFunReq(Functions_FUNC_GET_DB_TABLES)
-	str := &Request_Str{Str: tab}
-	req.Msg = str
-	err := c.send(req.build())
+	req.Msg = &Request_Str{Str: tab}
+	recv, err := c.call(ctx, req)
 	if err != nil {
-		logging.ErrorWithErr(err, "internal GetDBTables err")
+		return nil, err
 	}
-	recv, err := c.Recv()
-	if err != nil {
-		logging.ErrorWithErr(err, "internal GetDBTables err")
-	}
-	return recv.GetTables().GetTables()
+	return recv.GetTables().GetTables(), nil
 }
 
 // ExecDBQuery 执行sql
-func (c *Client) ExecDBQuery(db, sql string) []*DbRow {
+func (c *Client) ExecDBQuery(db, sql string) ([]*DbRow, error) {
+	return c.ExecDBQueryCtx(context.Background(), db, sql)
+}
+
+// ExecDBQueryCtx 同 ExecDBQuery，支持 ctx 取消与超时
+func (c *Client) ExecDBQueryCtx(ctx context.Context, db, sql string) ([]*DbRow, error) {
 	req := genFunReq(Functions_FUNC_EXEC_DB_QUERY)
-	q := Request_Query{
+	req.Msg = &Request_Query{
 		Query: &DbQuery{
 			Db:  db,
 			Sql: sql,
 		},
 	}
-	req.Msg = &q
-	err := c.send(req.build())
+	recv, err := c.call(ctx, req)
 	if err != nil {
-		logging.ErrorWithErr(err, "internal ExecDBQuery err")
+		return nil, err
 	}
-	recv, err := c.Recv()
-	if err != nil {
-		logging.ErrorWithErr(err, "internal ExecDBQuery err")
-	}
-	return recv.GetRows().GetRows()
+	return recv.GetRows().GetRows(), nil
 }
 
 // AcceptFriend 接收好友请求
-func (c *Client) AcceptFriend(v3, v4 string, scene int64) int32 {
+func (c *Client) AcceptFriend(v3, v4 string, scene int64) (int32, error) {
+	return c.AcceptFriendCtx(context.Background(), v3, v4, scene)
+}
+
+// AcceptFriendCtx 同 AcceptFriend，支持 ctx 取消与超时
+func (c *Client) AcceptFriendCtx(ctx context.Context, v3, v4 string, scene int64) (int32, error) {
 	req := genFunReq(Functions_FUNC_ACCEPT_FRIEND)
-	q := Request_V{
+	req.Msg = &Request_V{
 		V: &Verification{
 			V3:    v3,
 			V4:    v4,
 			Scene: scene,
 		}}
+	return c.callStatus(ctx, req)
+}
 
-	req.Msg = &q
-	err := c.send(req.build())
-	if err != nil {
-		logging.ErrorWithErr(err, "internal AcceptFriend err")
-	}
-	recv, err := c.Recv()
-	if err != nil {
-		logging.ErrorWithErr(err, "internal AcceptFriend err")
-	}
-	return recv.GetStatus()
+func (c *Client) AddChatroomMembers(roomID, wxIDs string) (int32, error) {
+	return c.AddChatroomMembersCtx(context.Background(), roomID, wxIDs)
 }
 
-func (c *Client) AddChatroomMembers(roomID, wxIDs string) int32 {
+// AddChatroomMembersCtx 同 AddChatroomMembers，支持 ctx 取消与超时
+func (c *Client) AddChatroomMembersCtx(ctx context.Context, roomID, wxIDs string) (int32, error) {
 	req := genFunReq(Functions_FUNC_ADD_ROOM_MEMBERS)
-	q := Request_M{
+	req.Msg = &Request_M{
 		M: &MemberMgmt{Roomid: roomID, Wxids: wxIDs},
 	}
-	req.Msg = &q
-	err := c.send(req.build())
-	if err != nil {
-		logging.ErrorWithErr(err, "internal AddChatroomMembers err")
-	}
-	recv, err := c.Recv()
-	if err != nil {
-		logging.ErrorWithErr(err, "internal AddChatroomMembers err")
-	}
-	return recv.GetStatus()
+	return c.callStatus(ctx, req)
 }
 
 // ReceiveTransfer 接收转账
-func (c *Client) ReceiveTransfer(wxid, tfid, taid string) int32 {
+func (c *Client) ReceiveTransfer(wxid, tfid, taid string) (int32, error) {
+	return c.ReceiveTransferCtx(context.Background(), wxid, tfid, taid)
+}
+
+// ReceiveTransferCtx 同 ReceiveTransfer，支持 ctx 取消与超时
+func (c *Client) ReceiveTransferCtx(ctx context.Context, wxid, tfid, taid string) (int32, error) {
 	req := genFunReq(Functions_FUNC_RECV_TRANSFER)
-	q := Request_Tf{
+	req.Msg = &Request_Tf{
 		Tf: &Transfer{
 			Wxid: wxid,
 			Tfid: tfid,
 			Taid: taid,
 		},
 	}
-	req.Msg = &q
-	err := c.send(req.build())
-	if err != nil {
-		logging.ErrorWithErr(err, "internal ReceiveTransfer err")
-	}
-	recv, err := c.Recv()
-	if err != nil {
-		logging.ErrorWithErr(err, "internal ReceiveTransfer err")
-	}
-	return recv.GetStatus()
+	return c.callStatus(ctx, req)
 }
 
-// RefreshPYQ 刷新朋友圈
-// Deprecated
-func (c *Client) RefreshPYQ() int32 {
+// RefreshPYQ 刷新朋友圈最新一页
+// Deprecated: 使用 RefreshPYQPage
+func (c *Client) RefreshPYQ() (int32, error) {
+	return c.RefreshPYQCtx(context.Background())
+}
+
+// RefreshPYQCtx 同 RefreshPYQ，支持 ctx 取消与超时
+// Deprecated: 使用 RefreshPYQPageCtx
+func (c *Client) RefreshPYQCtx(ctx context.Context) (int32, error) {
+	return c.RefreshPYQPageCtx(ctx, 0)
+}
+
+// RefreshPYQPage 刷新朋友圈 <开始 id，0 为最新页>，结果以 Type 为 0 的消息推送（需开启接收朋友圈消息）
+func (c *Client) RefreshPYQPage(id uint64) (int32, error) {
+	return c.RefreshPYQPageCtx(context.Background(), id)
+}
+
+// RefreshPYQPageCtx 同 RefreshPYQPage，支持 ctx 取消与超时
+func (c *Client) RefreshPYQPageCtx(ctx context.Context, id uint64) (int32, error) {
 	req := genFunReq(Functions_FUNC_REFRESH_PYQ)
-	q := Request_Ui64{
-		Ui64: 0,
+	req.Msg = &Request_Ui64{
+		Ui64: id,
 	}
-	req.Msg = &q
-	err := c.send(req.build())
-	if err != nil {
-		logging.ErrorWithErr(err, "internal RefreshPYQ err")
-	}
-	recv, err := c.Recv()
-	if err != nil {
-		logging.ErrorWithErr(err, "internal RefreshPYQ err")
-	}
-	return recv.GetStatus()
+	return c.callStatus(ctx, req)
 }
 
 // DecryptImage 解密图片 加密路径，解密路径
-func (c *Client) DecryptImage(src, dst string) string {
+func (c *Client) DecryptImage(src, dst string) (string, error) {
+	return c.DecryptImageCtx(context.Background(), src, dst)
+}
+
+// DecryptImageCtx 同 DecryptImage，支持 ctx 取消与超时
+func (c *Client) DecryptImageCtx(ctx context.Context, src, dst string) (string, error) {
 	req := genFunReq(Functions_FUNC_DECRYPT_IMAGE)
-	q := Request_Dec{
+	req.Msg = &Request_Dec{
 		Dec: &DecPath{Src: src, Dst: dst},
 	}
-	req.Msg = &q
-	err := c.send(req.build())
-	if err != nil {
-		logging.ErrorWithErr(err, "internal DecryptImage err")
-	}
-	recv, err := c.Recv()
+	recv, err := c.call(ctx, req)
 	if err != nil {
-		logging.ErrorWithErr(err, "internal DecryptImage err")
+		return "", err
 	}
-
-	return recv.String()
+	return recv.GetStr(), nil
 }
 
 // AddChatRoomMembers 添加群成员
-func (c *Client) AddChatRoomMembers(roomId string, wxIds []string) int32 {
+func (c *Client) AddChatRoomMembers(roomId string, wxIds []string) (int32, error) {
+	return c.AddChatRoomMembersCtx(context.Background(), roomId, wxIds)
+}
+
+// AddChatRoomMembersCtx 同 AddChatRoomMembers，支持 ctx 取消与超时
+func (c *Client) AddChatRoomMembersCtx(ctx context.Context, roomId string, wxIds []string) (int32, error) {
 	req := genFunReq(Functions_FUNC_ADD_ROOM_MEMBERS)
-	q := Request_M{
+	req.Msg = &Request_M{
 		M: &MemberMgmt{Roomid: roomId,
 			Wxids: strings.Join(wxIds, ",")},
 	}
-	req.Msg = &q
-	err := c.send(req.build())
-	if err != nil {
-		logging.ErrorWithErr(err, "internal AddChatRoomMembers err")
-	}
-	recv, err := c.Recv()
-	if err != nil {
-		logging.ErrorWithErr(err, "internal AddChatRoomMembers err")
-	}
-	return recv.GetStatus()
+	return c.callStatus(ctx, req)
 }
 
 // InvChatRoomMembers 邀请群成员
-func (c *Client) InvChatRoomMembers(roomId string, wxIds []string) int32 {
+func (c *Client) InvChatRoomMembers(roomId string, wxIds []string) (int32, error) {
+	return c.InvChatRoomMembersCtx(context.Background(), roomId, wxIds)
+}
+
+// InvChatRoomMembersCtx 同 InvChatRoomMembers，支持 ctx 取消与超时
+func (c *Client) InvChatRoomMembersCtx(ctx context.Context, roomId string, wxIds []string) (int32, error) {
 	req := genFunReq(Functions_FUNC_INV_ROOM_MEMBERS)
-	q := Request_M{
+	req.Msg = &Request_M{
 		M: &MemberMgmt{Roomid: roomId,
 			Wxids: strings.Join(wxIds, ",")},
 	}
-	req.Msg = &q
-	err := c.send(req.build())
-	if err != nil {
-		logging.ErrorWithErr(err, "internal InvChatRoomMembers err")
-	}
-	recv, err := c.Recv()
-	if err != nil {
-		logging.ErrorWithErr(err, "internal InvChatRoomMembers err")
-	}
-	return recv.GetStatus()
+	return c.callStatus(ctx, req)
 }
 
 // DelChatRoomMembers 删除群成员
-func (c *Client) DelChatRoomMembers(roomId string, wxIds []string) int32 {
+func (c *Client) DelChatRoomMembers(roomId string, wxIds []string) (int32, error) {
+	return c.DelChatRoomMembersCtx(context.Background(), roomId, wxIds)
+}
+
+// DelChatRoomMembersCtx 同 DelChatRoomMembers，支持 ctx 取消与超时
+func (c *Client) DelChatRoomMembersCtx(ctx context.Context, roomId string, wxIds []string) (int32, error) {
 	req := genFunReq(Functions_FUNC_DEL_ROOM_MEMBERS)
-	q := Request_M{
+	req.Msg = &Request_M{
 		M: &MemberMgmt{Roomid: roomId,
 			Wxids: strings.Join(wxIds, ",")},
 	}
-	req.Msg = &q
-	err := c.send(req.build())
-	if err != nil {
-		logging.ErrorWithErr(err, "internal DelChatRoomMembers err")
-	}
-	recv, err := c.Recv()
-	if err != nil {
-		logging.ErrorWithErr(err, "internal DelChatRoomMembers err")
-	}
-	return recv.GetStatus()
+	return c.callStatus(ctx, req)
 }
 
 // GetUserInfo 获取自己的信息
-func (c *Client) GetUserInfo() *UserInfo {
-	err := c.send(genFunReq(Functions_FUNC_GET_USER_INFO).build())
-	if err != nil {
-		logging.ErrorWithErr(err, "internal getFriend err")
-	}
-	recv, err := c.Recv()
+func (c *Client) GetUserInfo() (*UserInfo, error) {
+	return c.GetUserInfoCtx(context.Background())
+}
+
+// GetUserInfoCtx 同 GetUserInfo，支持 ctx 取消与超时
+func (c *Client) GetUserInfoCtx(ctx context.Context) (*UserInfo, error) {
+	recv, err := c.call(ctx, genFunReq(Functions_FUNC_GET_USER_INFO))
 	if err != nil {
-		logging.ErrorWithErr(err, "internal getFriend err")
+		return nil, err
 	}
-	return recv.GetUi()
+	return recv.GetUi(), nil
 }
 
 // SendTxt 发送文本内容
-func (c *Client) SendTxt(msg string, receiver string, ates []string) int32 {
+func (c *Client) SendTxt(msg string, receiver string, ates []string) (int32, error) {
+	return c.SendTxtCtx(context.Background(), msg, receiver, ates)
+}
+
+// SendTxtCtx 同 SendTxt，支持 ctx 取消与超时
+func (c *Client) SendTxtCtx(ctx context.Context, msg string, receiver string, ates []string) (int32, error) {
 	req := genFunReq(Functions_FUNC_SEND_TXT)
 	req.Msg = &Request_Txt{
 		Txt: &TextMsg{
@@ -345,19 +553,16 @@ func (c *Client) SendTxt(msg string, receiver string, ates []string) int32 {
 			Aters:    strings.Join(ates, ","),
 		},
 	}
-	err := c.send(req.build())
-	if err != nil {
-		logging.ErrorWithErr(err, "internal SendTxt err")
-	}
-	recv, err := c.Recv()
-	if err != nil {
-		logging.ErrorWithErr(err, "internal SendTxt err")
-	}
-	return recv.GetStatus()
+	return c.callStatus(ctx, req)
 }
 
 // ForwardMsg 转发消息
-func (c *Client) ForwardMsg(Id uint64, receiver string) int32 {
+func (c *Client) ForwardMsg(Id uint64, receiver string) (int32, error) {
+	return c.ForwardMsgCtx(context.Background(), Id, receiver)
+}
+
+// ForwardMsgCtx 同 ForwardMsg，支持 ctx 取消与超时
+func (c *Client) ForwardMsgCtx(ctx context.Context, Id uint64, receiver string) (int32, error) {
 	req := genFunReq(Functions_FUNC_FORWARD_MSG)
 	req.Msg = &Request_Fm{
 		Fm: &ForwardMsg{
@@ -365,19 +570,69 @@ func (c *Client) ForwardMsg(Id uint64, receiver string) int32 {
 			Receiver: receiver,
 		},
 	}
-	err := c.send(req.build())
+	return c.callStatus(ctx, req)
+}
+
+// RevokeMsg 撤回消息 <消息 id>
+func (c *Client) RevokeMsg(id uint64) (int32, error) {
+	return c.RevokeMsgCtx(context.Background(), id)
+}
+
+// RevokeMsgCtx 同 RevokeMsg，支持 ctx 取消与超时
+func (c *Client) RevokeMsgCtx(ctx context.Context, id uint64) (int32, error) {
+	req := genFunReq(Functions_FUNC_REVOKE_MSG)
+	req.Msg = &Request_Ui64{
+		Ui64: id,
+	}
+	return c.callStatus(ctx, req)
+}
+
+// GetAudioMsg 将语音消息转为 MP3 保存到微信所在主机的目录 <消息 id> <保存目录>，返回文件路径，语音尚未入库时为空
+func (c *Client) GetAudioMsg(id uint64, dir string) (string, error) {
+	return c.GetAudioMsgCtx(context.Background(), id, dir)
+}
+
+// GetAudioMsgCtx 同 GetAudioMsg，支持 ctx 取消与超时
+func (c *Client) GetAudioMsgCtx(ctx context.Context, id uint64, dir string) (string, error) {
+	req := genFunReq(Functions_FUNC_GET_AUDIO_MSG)
+	req.Msg = &Request_Am{
+		Am: &AudioMsg{Id: id, Dir: dir},
+	}
+	recv, err := c.call(ctx, req)
 	if err != nil {
-		logging.ErrorWithErr(err, "internal ForwardMsg err")
+		return "", err
 	}
-	recv, err := c.Recv()
+	return recv.GetStr(), nil
+}
+
+// ExecOCR 识别图片中的文字 <消息中的 extra>，Status 为 0 时结果可用，非 0 表示尚未就绪
+func (c *Client) ExecOCR(extra string) (*OcrMsg, error) {
+	return c.ExecOCRCtx(context.Background(), extra)
+}
+
+// ExecOCRCtx 同 ExecOCR，支持 ctx 取消与超时
+func (c *Client) ExecOCRCtx(ctx context.Context, extra string) (*OcrMsg, error) {
+	req := genFunReq(Functions_FUNC_EXEC_OCR)
+	req.Msg = &Request_Str{
+		Str: extra,
+	}
+	recv, err := c.call(ctx, req)
 	if err != nil {
-		logging.ErrorWithErr(err, "internal ForwardMsg err")
+		return nil, err
+	}
+	if recv.GetOcr() == nil {
+		return nil, fmt.Errorf("%s: %w: missing ocr result", req.Func, ErrDecode)
 	}
-	return recv.GetStatus()
+	return recv.GetOcr(), nil
 }
 
 // SendIMG 发送图片
-func (c *Client) SendIMG(path string, receiver string) int32 {
+func (c *Client) SendIMG(path string, receiver string) (int32, error) {
+	return c.SendIMGCtx(context.Background(), path, receiver)
+}
+
+// SendIMGCtx 同 SendIMG，支持 ctx 取消与超时
+func (c *Client) SendIMGCtx(ctx context.Context, path string, receiver string) (int32, error) {
 	req := genFunReq(Functions_FUNC_SEND_IMG)
 	req.Msg = &Request_File{
 		File: &PathMsg{
@@ -385,19 +640,16 @@ func (c *Client) SendIMG(path string, receiver string) int32 {
 			Receiver: receiver,
 		},
 	}
-	err := c.send(req.build())
-	if err != nil {
-		logging.ErrorWithErr(err, "internal SendIMG err")
-	}
-	recv, err := c.Recv()
-	if err != nil {
-		logging.ErrorWithErr(err, "internal SendIMG err")
-	}
-	return recv.GetStatus()
+	return c.callStatus(ctx, req)
 }
 
 // SendFile 发送文件
-func (c *Client) SendFile(path string, receiver string) int32 {
+func (c *Client) SendFile(path string, receiver string) (int32, error) {
+	return c.SendFileCtx(context.Background(), path, receiver)
+}
+
+// SendFileCtx 同 SendFile，支持 ctx 取消与超时
+func (c *Client) SendFileCtx(ctx context.Context, path string, receiver string) (int32, error) {
 	req := genFunReq(Functions_FUNC_SEND_FILE)
 	req.Msg = &Request_File{
 		File: &PathMsg{
@@ -405,19 +657,16 @@ func (c *Client) SendFile(path string, receiver string) int32 {
 			Receiver: receiver,
 		},
 	}
-	err := c.send(req.build())
-	if err != nil {
-		logging.ErrorWithErr(err, "internal SendFile err")
-	}
-	recv, err := c.Recv()
-	if err != nil {
-		logging.ErrorWithErr(err, "internal SendFile err")
-	}
-	return recv.GetStatus()
+	return c.callStatus(ctx, req)
 }
 
 // SendRichText 发送卡片消息
-func (c *Client) SendRichText(name string, account string, title string, digest string, url string, thumburl string, receiver string) int32 {
+func (c *Client) SendRichText(name string, account string, title string, digest string, url string, thumburl string, receiver string) (int32, error) {
+	return c.SendRichTextCtx(context.Background(), name, account, title, digest, url, thumburl, receiver)
+}
+
+// SendRichTextCtx 同 SendRichText，支持 ctx 取消与超时
+func (c *Client) SendRichTextCtx(ctx context.Context, name string, account string, title string, digest string, url string, thumburl string, receiver string) (int32, error) {
 	req := genFunReq(Functions_FUNC_SEND_RICH_TXT)
 	req.Msg = &Request_Rt{
 		Rt: &RichText{
@@ -430,19 +679,16 @@ func (c *Client) SendRichText(name string, account string, title string, digest
 			Receiver: receiver,
 		},
 	}
-	err := c.send(req.build())
-	if err != nil {
-		logging.ErrorWithErr(err, "internal SendRichText err")
-	}
-	recv, err := c.Recv()
-	if err != nil {
-		logging.ErrorWithErr(err, "internal SendRichText err")
-	}
-	return recv.GetStatus()
+	return c.callStatus(ctx, req)
 }
 
 // SendXml 发送xml数据
-func (c *Client) SendXml(path, content, receiver string, Type int32) int32 {
+func (c *Client) SendXml(path, content, receiver string, Type int32) (int32, error) {
+	return c.SendXmlCtx(context.Background(), path, content, receiver, Type)
+}
+
+// SendXmlCtx 同 SendXml，支持 ctx 取消与超时
+func (c *Client) SendXmlCtx(ctx context.Context, path, content, receiver string, Type int32) (int32, error) {
 	req := genFunReq(Functions_FUNC_SEND_XML)
 	req.Msg = &Request_Xml{
 		Xml: &XmlMsg{
@@ -452,20 +698,17 @@ func (c *Client) SendXml(path, content, receiver string, Type int32) int32 {
 			Type:     Type,
 		},
 	}
-	err := c.send(req.build())
-	if err != nil {
-		logging.ErrorWithErr(err, "internal SendXml err")
-	}
-	recv, err := c.Recv()
-	if err != nil {
-		logging.ErrorWithErr(err, "internal SendXml err")
-	}
-	return recv.GetStatus()
+	return c.callStatus(ctx, req)
 }
 
 // SendEmotion 发送emoji  发送既崩溃
 // Deprecated
-func (c *Client) SendEmotion(path, receiver string) int32 {
+func (c *Client) SendEmotion(path, receiver string) (int32, error) {
+	return c.SendEmotionCtx(context.Background(), path, receiver)
+}
+
+// SendEmotionCtx 同 SendEmotion，支持 ctx 取消与超时
+func (c *Client) SendEmotionCtx(ctx context.Context, path, receiver string) (int32, error) {
 	req := genFunReq(Functions_FUNC_SEND_EMOTION)
 	req.Msg = &Request_File{
 		File: &PathMsg{
@@ -473,19 +716,16 @@ func (c *Client) SendEmotion(path, receiver string) int32 {
 			Receiver: receiver,
 		},
 	}
-	err := c.send(req.build())
-	if err != nil {
-		logging.ErrorWithErr(err, "internal is_login err")
-	}
-	recv, err := c.Recv()
-	if err != nil {
-		logging.ErrorWithErr(err, "internal is_login err")
-	}
-	return recv.GetStatus()
+	return c.callStatus(ctx, req)
 }
 
 // SendPat 发送拍一拍消息
-func (c *Client) SendPat(roomId, wxId string) int32 {
+func (c *Client) SendPat(roomId, wxId string) (int32, error) {
+	return c.SendPatCtx(context.Background(), roomId, wxId)
+}
+
+// SendPatCtx 同 SendPat，支持 ctx 取消与超时
+func (c *Client) SendPatCtx(ctx context.Context, roomId, wxId string) (int32, error) {
 	req := genFunReq(Functions_FUNC_SEND_PAT_MSG)
 	req.Msg = &Request_Pm{
 		Pm: &PatMsg{
@@ -493,19 +733,16 @@ func (c *Client) SendPat(roomId, wxId string) int32 {
 			Wxid:   wxId,
 		},
 	}
-	err := c.send(req.build())
-	if err != nil {
-		logging.ErrorWithErr(err, "internal is_login err")
-	}
-	recv, err := c.Recv()
-	if err != nil {
-		logging.ErrorWithErr(err, "internal is_login err")
-	}
-	return recv.GetStatus()
+	return c.callStatus(ctx, req)
 }
 
 // DownloadAttach 下载附件
-func (c *Client) DownloadAttach(id uint64, thumb, extra string) int32 {
+func (c *Client) DownloadAttach(id uint64, thumb, extra string) (int32, error) {
+	return c.DownloadAttachCtx(context.Background(), id, thumb, extra)
+}
+
+// DownloadAttachCtx 同 DownloadAttach，支持 ctx 取消与超时
+func (c *Client) DownloadAttachCtx(ctx context.Context, id uint64, thumb, extra string) (int32, error) {
 	req := genFunReq(Functions_FUNC_DOWNLOAD_ATTACH)
 	req.Msg = &Request_Att{
 		Att: &AttachMsg{
@@ -514,83 +751,104 @@ func (c *Client) DownloadAttach(id uint64, thumb, extra string) int32 {
 			Extra: extra,
 		},
 	}
-	err := c.send(req.build())
-	if err != nil {
-		logging.ErrorWithErr(err, "internal is_login err")
-	}
-	recv, err := c.Recv()
-	if err != nil {
-		logging.ErrorWithErr(err, "internal is_login err")
-	}
-	return recv.GetStatus()
+	return c.callStatus(ctx, req)
 }
 
 // EnableRecvTxt 开启接收数据
-func (c *Client) EnableRecvTxt() int32 {
+func (c *Client) EnableRecvTxt() (int32, error) {
+	return c.EnableRecvTxtCtx(context.Background())
+}
+
+// EnableRecvTxtCtx 同 EnableRecvTxt，支持 ctx 取消与超时
+func (c *Client) EnableRecvTxtCtx(ctx context.Context) (int32, error) {
 	req := genFunReq(Functions_FUNC_ENABLE_RECV_TXT)
 	req.Msg = &Request_Flag{
 		Flag: true,
 	}
-	err := c.send(req.build())
-	if err != nil {
-		logging.ErrorWithErr(err, "internal is_login err")
-	}
-	recv, err := c.Recv()
+	status, err := c.callStatus(ctx, req)
 	if err != nil {
-		logging.ErrorWithErr(err, "internal is_login err")
+		return status, err
 	}
-	c.RecvTxt = true
-	return recv.GetStatus()
+	c.RecvTxt.Store(true)
+	return status, nil
 }
 
 // DisableRecvTxt 关闭接收消息
-func (c *Client) DisableRecvTxt() int32 {
-	err := c.send(genFunReq(Functions_FUNC_DISABLE_RECV_TXT).build())
-	if err != nil {
-		logging.ErrorWithErr(err, "internal is_login err")
-	}
-	recv, err := c.Recv()
+func (c *Client) DisableRecvTxt() (int32, error) {
+	return c.DisableRecvTxtCtx(context.Background())
+}
+
+// DisableRecvTxtCtx 同 DisableRecvTxt，支持 ctx 取消与超时
+func (c *Client) DisableRecvTxtCtx(ctx context.Context) (int32, error) {
+	status, err := c.callStatus(ctx, genFunReq(Functions_FUNC_DISABLE_RECV_TXT))
 	if err != nil {
-		logging.ErrorWithErr(err, "internal is_login err")
+		return status, err
 	}
-	c.RecvTxt = false
-	return recv.GetStatus()
+	c.RecvTxt.Store(false)
+	return status, nil
 }
 
 type MsgHandler func(msg *WxMsg) error
 
 // OnMSG 接收消息
 func (c *Client) OnMSG(ctx context.Context, f MsgHandler) error {
+	return c.OnMSGReady(ctx, f, nil)
+}
+
+// OnMSGReady 同 OnMSG，消息端口拨号成功后调用 ready
+func (c *Client) OnMSGReady(ctx context.Context, f MsgHandler, ready func()) error {
 	socket, err := pair1.NewSocket()
 	if err != nil {
 		return err
 	}
-	_ = socket.SetOption(mangos.OptionRecvDeadline, 5000)
-	_ = socket.SetOption(mangos.OptionSendDeadline, 5000)
+	_ = socket.SetOption(mangos.OptionRecvDeadline, time.Second) // 定期醒来检查 ctx
+	_ = socket.SetOption(mangos.OptionSendDeadline, 5*time.Second)
+	detached := make(chan struct{}, 1)
+	socket.SetPipeEventHook(func(ev mangos.PipeEvent, _ mangos.Pipe) {
+		if ev == mangos.PipeEventDetached { // 对端断开（微信重启、RPC 主机掉线等）
+			select {
+			case detached <- struct{}{}:
+			default:
+			}
+		}
+	})
+	defer socket.Close()                                          // 拨号失败时同样关闭，避免每次重连泄漏 socket
+	defer context.AfterFunc(ctx, func() { _ = socket.Close() })() // ctx 结束时立即关闭，不等待 Recv 超时
 	err = socket.Dial(addPort(c.add))
 	if err != nil {
-		return err
+		return wrapSocketErr(err)
+	}
+	pool := c.msgPool()
+	if ready != nil {
+		ready()
 	}
-	defer socket.Close()
-	for c.RecvTxt {
+	for c.RecvTxt.Load() {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
+		case <-detached:
+			return fmt.Errorf("%w: message socket disconnected", ErrTransport)
 		default:
 			// pass
 		}
 		msg := &Response{}
 		recv, err := socket.Recv()
+		if errors.Is(err, mangos.ErrRecvTimeout) { // 暂无消息
+			continue
+		}
 		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return wrapSocketErr(err)
+		}
+		if err = proto.Unmarshal(recv, msg); err != nil {
+			c.logger.Warn(err, "wcf: decode message", map[string]interface{}{"len": len(recv)})
+			continue
+		}
+		if err = pool.submit(ctx, poolTask{msg: msg.GetWxmsg(), f: f}); err != nil { // 交给协程池处理
 			return err
 		}
-		_ = proto.Unmarshal(recv, msg)
-		go func() {
-			err := f(msg.GetWxmsg())
-			if err != nil {
-				err = fmt.Errorf("onMsg err: %w", err)
-			}
-		}()
 
 	}
 	return err
@@ -601,7 +859,8 @@ func NewWCF(add string) (*Client, error) {
 	if add == "" {
 		add = "tcp://127.0.0.1:10086"
 	}
-	client := &Client{add: add}
+	client := &Client{add: add, callSem: make(chan struct{}, 1), logger: logutil.Nop{}, poolCfg: DefaultPoolConfig()}
+	client.timeout.Store(int64(DefaultTimeout))
 	err := client.conn()
 	return client, err
 }
@@ -610,9 +869,12 @@ type cmdMSG struct {
 	*Request
 }
 
-func (c *cmdMSG) build() []byte {
-	marshal, _ := proto.Marshal(c)
-	return marshal
+func (c *cmdMSG) build() ([]byte, error) {
+	marshal, err := proto.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("%s: marshal request: %w", c.Func, err)
+	}
+	return marshal, nil
 }
 
 func genFunReq(fun Functions) *cmdMSG {
@@ -622,9 +884,13 @@ func genFunReq(fun Functions) *cmdMSG {
 	}
 }
 
+// addPort 消息端口为命令端口 +1，兼容 tcp://host:port 与 ws://host:port/path
 func addPort(add string) string {
-	parts := strings.Split(add, ":")
-	port, _ := strconv.Atoi(parts[2])
-	newPort := port + 1
-	return parts[0] + ":" + parts[1] + ":" + strconv.Itoa(newPort)
+	u, err := url.Parse(add)
+	if err != nil {
+		return add
+	}
+	port, _ := strconv.Atoi(u.Port())
+	u.Host = net.JoinHostPort(u.Hostname(), strconv.Itoa(port+1))
+	return u.String()
 }
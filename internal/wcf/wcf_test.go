@@ -1,11 +1,15 @@
-package wcf
+package wcf_test
 
 import (
 	"bytes"
 	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"github.com/Clov614/wcf-rpc-sdk/internal/wcf"
+	"github.com/Clov614/wcf-rpc-sdk/internal/wcftest"
 	"os"
+	"sync"
 	"testing"
 	"time"
 )
@@ -13,26 +17,69 @@ import (
 // const testAddr = "tcp://192.168.150.128:10086" // 请修改为你的 WCF 服务端地址
 var testAddr = os.Getenv("TEST_ADDR") // 请修改为你的 WCF 服务端地址
 
+var fakeSrv *wcftest.Server // 未设置 TEST_ADDR 时的模拟服务端
+
+// TestMain 未设置 TEST_ADDR 时使用 wcftest 模拟服务端
+func TestMain(m *testing.M) {
+	if testAddr != "" {
+		os.Exit(m.Run())
+	}
+	srv, err := wcftest.NewServer()
+	if err != nil {
+		fmt.Println("wcftest.NewServer:", err)
+		os.Exit(1)
+	}
+	seedFakeServer(srv)
+	fakeSrv = srv
+	testAddr = srv.Addr()
+	code := m.Run()
+	_ = srv.Close()
+	os.Exit(code)
+}
+
+// seedFakeServer 填充模拟服务端的测试数据
+func seedFakeServer(srv *wcftest.Server) {
+	srv.SetUserInfo(&wcf.UserInfo{Wxid: "wxid_p5z4fuhnbdgs22", Name: "bot", Home: "C:/Users/Administrator/Documents/WeChat Files/"})
+	srv.SetMsgTypes(map[int32]string{1: "文字", 3: "图片", 49: "xml消息"})
+	srv.SetContacts(
+		&wcf.RpcContact{Wxid: "wxid_pagpb98c6nj722", Name: "friendA"},
+		&wcf.RpcContact{Wxid: "wxid_jj4mhsji9tjk22", Name: "friendB"},
+		&wcf.RpcContact{Wxid: "45959390469@chatroom", Name: "测试12"},
+	)
+	srv.SetDBTables("MicroMsg.db", &wcf.DbTable{Name: "Contact", Sql: "CREATE TABLE Contact(UserName TEXT)"})
+	srv.SetQuery("MicroMsg.db", "SELECT UserName, NickName FROM Contact limit 10;",
+		wcftest.Row("UserName", "wxid_pagpb98c6nj722", "NickName", "friendA"))
+	srv.SetQuery("MicroMsg.db", "select * from Contact;",
+		wcftest.Row("UserName", "wxid_pagpb98c6nj722", "NickName", "friendA"))
+}
+
 func TestClient_IsLogin(t *testing.T) {
-	c, err := NewWCF(testAddr)
+	c, err := wcf.NewWCF(testAddr)
 	if err != nil {
-		t.Fatalf("NewWCF() error = %v", err)
+		t.Fatalf("wcf.NewWCF() error = %v", err)
 	}
 	defer c.Close()
 
-	if !c.IsLogin() {
+	isLogin, err := c.IsLogin()
+	if err != nil {
+		t.Fatalf("IsLogin() error = %v", err)
+	}
+	if !isLogin {
 		t.Errorf("IsLogin() = false, want true")
 	}
 }
 
 func TestClient_GetSelfWXID(t *testing.T) {
-	c, err := NewWCF(testAddr)
+	c, err := wcf.NewWCF(testAddr)
 	if err != nil {
-		t.Fatalf("NewWCF() error = %v", err)
+		t.Fatalf("wcf.NewWCF() error = %v", err)
 	}
 	defer c.Close()
 
-	wxid := c.GetSelfWXID()
+	wxid, err := c.GetSelfWXID()
+	if err != nil {
+		t.Fatalf("GetSelfWXID() error = %v", err)
+	}
 	if wxid == "" {
 		t.Errorf("GetSelfWXID() = \"\", want non-empty string")
 	}
@@ -40,13 +87,16 @@ func TestClient_GetSelfWXID(t *testing.T) {
 }
 
 func TestClient_GetMsgTypes(t *testing.T) {
-	c, err := NewWCF(testAddr)
+	c, err := wcf.NewWCF(testAddr)
 	if err != nil {
-		t.Fatalf("NewWCF() error = %v", err)
+		t.Fatalf("wcf.NewWCF() error = %v", err)
 	}
 	defer c.Close()
 
-	types := c.GetMsgTypes()
+	types, err := c.GetMsgTypes()
+	if err != nil {
+		t.Fatalf("GetMsgTypes() error = %v", err)
+	}
 	if len(types) == 0 {
 		t.Errorf("GetMsgTypes() returned empty map")
 	}
@@ -54,13 +104,16 @@ func TestClient_GetMsgTypes(t *testing.T) {
 }
 
 func TestClient_GetContacts(t *testing.T) {
-	c, err := NewWCF(testAddr)
+	c, err := wcf.NewWCF(testAddr)
 	if err != nil {
-		t.Fatalf("NewWCF() error = %v", err)
+		t.Fatalf("wcf.NewWCF() error = %v", err)
 	}
 	defer c.Close()
 
-	contacts := c.GetContacts()
+	contacts, err := c.GetContacts()
+	if err != nil {
+		t.Fatalf("GetContacts() error = %v", err)
+	}
 	if len(contacts) == 0 {
 		t.Errorf("GetContacts() returned empty list")
 	}
@@ -68,13 +121,16 @@ func TestClient_GetContacts(t *testing.T) {
 }
 
 func TestClient_GetDBNames(t *testing.T) {
-	c, err := NewWCF(testAddr)
+	c, err := wcf.NewWCF(testAddr)
 	if err != nil {
-		t.Fatalf("NewWCF() error = %v", err)
+		t.Fatalf("wcf.NewWCF() error = %v", err)
 	}
 	defer c.Close()
 
-	dbNames := c.GetDBNames()
+	dbNames, err := c.GetDBNames()
+	if err != nil {
+		t.Fatalf("GetDBNames() error = %v", err)
+	}
 	if len(dbNames) == 0 {
 		t.Errorf("GetDBNames() returned empty list")
 	}
@@ -82,18 +138,24 @@ func TestClient_GetDBNames(t *testing.T) {
 }
 
 func TestClient_GetDBTables(t *testing.T) {
-	c, err := NewWCF(testAddr)
+	c, err := wcf.NewWCF(testAddr)
 	if err != nil {
-		t.Fatalf("NewWCF() error = %v", err)
+		t.Fatalf("wcf.NewWCF() error = %v", err)
 	}
 	defer c.Close()
 
-	dbNames := c.GetDBNames()
+	dbNames, err := c.GetDBNames()
+	if err != nil {
+		t.Fatalf("GetDBNames() error = %v", err)
+	}
 	if len(dbNames) == 0 {
 		t.Skip("No databases found, skipping GetDBTables test")
 	}
 
-	tables := c.GetDBTables(dbNames[0]) // 获取第一个数据库的表
+	tables, err := c.GetDBTables(dbNames[0])
+	if err != nil {
+		t.Fatalf("GetDBTables() error = %v", err)
+	} // 获取第一个数据库的表
 	if len(tables) == 0 {
 		t.Errorf("GetDBTables() returned empty list")
 	}
@@ -101,19 +163,25 @@ func TestClient_GetDBTables(t *testing.T) {
 }
 
 func TestClient_ExecDBQuery(t *testing.T) {
-	c, err := NewWCF(testAddr)
+	c, err := wcf.NewWCF(testAddr)
 	if err != nil {
-		t.Fatalf("NewWCF() error = %v", err)
+		t.Fatalf("wcf.NewWCF() error = %v", err)
 	}
 	defer c.Close()
 
-	dbNames := c.GetDBNames()
+	dbNames, err := c.GetDBNames()
+	if err != nil {
+		t.Fatalf("GetDBNames() error = %v", err)
+	}
 	if len(dbNames) == 0 {
 		t.Skip("No databases found, skipping ExecDBQuery test")
 	}
 
 	// 测试查询 Contact 表
-	rows := c.ExecDBQuery("MicroMsg.db", "SELECT UserName, NickName FROM Contact limit 10;")
+	rows, err := c.ExecDBQuery("MicroMsg.db", "SELECT UserName, NickName FROM Contact limit 10;")
+	if err != nil {
+		t.Fatalf("ExecDBQuery() error = %v", err)
+	}
 	if len(rows) == 0 {
 		t.Errorf("ExecDBQuery() returned empty list")
 	}
@@ -121,81 +189,109 @@ func TestClient_ExecDBQuery(t *testing.T) {
 }
 
 func TestClient_SendTxt(t *testing.T) {
-	c, err := NewWCF(testAddr)
+	c, err := wcf.NewWCF(testAddr)
 	if err != nil {
-		t.Fatalf("NewWCF() error = %v", err)
+		t.Fatalf("wcf.NewWCF() error = %v", err)
 	}
-	defer func(c *Client) {
+	defer func(c *wcf.Client) {
 		err := c.Close()
 		if err != nil {
 			t.Error(err)
 		}
 	}(c)
 
-	wxid := c.GetSelfWXID()
+	wxid, err := c.GetSelfWXID()
+	if err != nil {
+		t.Fatalf("GetSelfWXID() error = %v", err)
+	}
 	if wxid == "" {
 		t.Skip("SelfWXID is empty, skipping SendTxt test")
 	}
 
-	status := c.SendTxt("Test from Go", wxid, nil)
+	status, err := c.SendTxt("Test from Go", wxid, nil)
+	if err != nil {
+		t.Fatalf("SendTxt() error = %v", err)
+	}
 	if status != 0 {
 		t.Errorf("SendTxt() = %v, want 0", status)
 	}
 }
 
 func TestClient_EnableRecvTxt(t *testing.T) {
-	c, err := NewWCF(testAddr)
+	c, err := wcf.NewWCF(testAddr)
 	if err != nil {
-		t.Fatalf("NewWCF() error = %v", err)
+		t.Fatalf("wcf.NewWCF() error = %v", err)
 	}
 	defer c.Close()
 
-	status := c.EnableRecvTxt()
+	status, err := c.EnableRecvTxt()
+	if err != nil {
+		t.Fatalf("EnableRecvTxt() error = %v", err)
+	}
 	if status != 0 {
 		t.Errorf("EnableRecvTxt() = %v, want 0", status)
 	}
 
-	if !c.RecvTxt {
+	if !c.RecvTxt.Load() {
 		t.Errorf("EnableRecvTxt() RecvTxt not set to true")
 	}
 }
 
 func TestClient_DisableRecvTxt(t *testing.T) {
-	c, err := NewWCF(testAddr)
+	c, err := wcf.NewWCF(testAddr)
 	if err != nil {
-		t.Fatalf("NewWCF() error = %v", err)
+		t.Fatalf("wcf.NewWCF() error = %v", err)
 	}
 	defer c.Close()
 
 	c.EnableRecvTxt() // 先启用接收消息
 
-	status := c.DisableRecvTxt()
+	status, err := c.DisableRecvTxt()
+	if err != nil {
+		t.Fatalf("DisableRecvTxt() error = %v", err)
+	}
 	if status != 0 {
 		t.Errorf("DisableRecvTxt() = %v, want 0", status)
 	}
 
-	if c.RecvTxt {
+	if c.RecvTxt.Load() {
 		t.Errorf("DisableRecvTxt() RecvTxt not set to false")
 	}
 }
 
 func TestClient_OnMSG(t *testing.T) {
-	c, err := NewWCF(testAddr)
+	c, err := wcf.NewWCF(testAddr)
 	if err != nil {
-		t.Fatalf("NewWCF() error = %v", err)
+		t.Fatalf("wcf.NewWCF() error = %v", err)
 	}
 	defer c.Close()
 
 	c.EnableRecvTxt() // 启用接收消息
 
-	msgChan := make(chan *WxMsg)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	defer func() { // 测试结束前停止接收并等待协程退出
+		cancel()
+		<-done
+	}()
+	msgChan := make(chan *wcf.WxMsg, 1)
 	go func() {
-		var msgHandler MsgHandler = func(msg *WxMsg) error {
-			msgChan <- msg
+		defer close(done)
+		var msgHandler wcf.MsgHandler = func(msg *wcf.WxMsg) error {
+			select {
+			case msgChan <- msg:
+			default:
+			}
 			return nil
 		}
-		err := c.OnMSG(context.Background(), msgHandler)
-		if err != nil {
+		err := c.OnMSGReady(ctx, msgHandler, func() {
+			if fakeSrv != nil { // 每次运行推送自己的测试消息，真实服务端则等待新消息
+				if err := fakeSrv.Push(&wcf.WxMsg{Id: 1, Type: 1, Sender: "wxid_pagpb98c6nj722", Content: "hello"}); err != nil {
+					t.Errorf("Push() error = %v", err)
+				}
+			}
+		})
+		if err != nil && !errors.Is(err, context.Canceled) {
 			t.Errorf("OnMSG() error = %v", err)
 		}
 	}()
@@ -209,14 +305,52 @@ func TestClient_OnMSG(t *testing.T) {
 	}
 }
 
+// TestClient_OnMSGDisable 接收消息期间关闭接收，OnMSG 随之退出
+func TestClient_OnMSGDisable(t *testing.T) {
+	srv, err := wcftest.NewServer()
+	if err != nil {
+		t.Fatalf("wcftest.NewServer() error = %v", err)
+	}
+	defer srv.Close()
+	c, err := wcf.NewWCF(srv.Addr())
+	if err != nil {
+		t.Fatalf("wcf.NewWCF() error = %v", err)
+	}
+	defer c.Close()
+	if _, err = c.EnableRecvTxt(); err != nil {
+		t.Fatalf("EnableRecvTxt() error = %v", err)
+	}
+
+	ready := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- c.OnMSGReady(context.Background(), func(msg *wcf.WxMsg) error { return nil }, func() { close(ready) })
+	}()
+	<-ready
+	if _, err = c.DisableRecvTxt(); err != nil {
+		t.Fatalf("DisableRecvTxt() error = %v", err)
+	}
+	select {
+	case err = <-done:
+		if err != nil {
+			t.Errorf("OnMSG() error = %v, want nil after DisableRecvTxt", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnMSG() did not return after DisableRecvTxt")
+	}
+}
+
 func TestClient_GetUserInfo(t *testing.T) {
-	c, err := NewWCF(testAddr)
+	c, err := wcf.NewWCF(testAddr)
 	if err != nil {
-		t.Fatalf("NewWCF() error = %v", err)
+		t.Fatalf("wcf.NewWCF() error = %v", err)
 	}
 	defer c.Close()
 
-	ui := c.GetUserInfo()
+	ui, err := c.GetUserInfo()
+	if err != nil {
+		t.Fatalf("GetUserInfo() error = %v", err)
+	}
 	if ui == nil {
 		t.Errorf("getFriend() returned nil")
 	}
@@ -224,26 +358,32 @@ func TestClient_GetUserInfo(t *testing.T) {
 }
 
 func TestClient_RefreshPYQ(t *testing.T) {
-	c, err := NewWCF(testAddr)
+	c, err := wcf.NewWCF(testAddr)
 	if err != nil {
-		t.Fatalf("NewWCF() error = %v", err)
+		t.Fatalf("wcf.NewWCF() error = %v", err)
 	}
 	defer c.Close()
 
-	status := c.RefreshPYQ()
+	status, err := c.RefreshPYQ()
+	if err != nil {
+		t.Fatalf("RefreshPYQ() error = %v", err)
+	}
 	if status != 1 {
 		t.Errorf("RefreshPYQ() = %v, want 1", status)
 	}
 }
 
 func TestClient_AddChatRoomMembers(t *testing.T) {
-	c, err := NewWCF(testAddr)
+	c, err := wcf.NewWCF(testAddr)
 	if err != nil {
-		t.Fatalf("NewWCF() error = %v", err)
+		t.Fatalf("wcf.NewWCF() error = %v", err)
 	}
 	defer c.Close()
 
-	contacts := c.GetContacts()
+	contacts, err := c.GetContacts()
+	if err != nil {
+		t.Fatalf("GetContacts() error = %v", err)
+	}
 	if len(contacts) < 2 {
 		t.Skip("Not enough contacts to test AddChatRoomMembers, skipping")
 	}
@@ -254,20 +394,26 @@ func TestClient_AddChatRoomMembers(t *testing.T) {
 	// 假设你有一个测试群，请替换为你的测试群 ID
 	roomID := "45959390469@chatroom" // 请替换为你的测试群 ID
 
-	status := c.AddChatRoomMembers(roomID, wxids)
+	status, err := c.AddChatRoomMembers(roomID, wxids)
+	if err != nil {
+		t.Fatalf("AddChatRoomMembers() error = %v", err)
+	}
 	if status != 1 {
 		t.Errorf("AddChatRoomMembers() = %v, want 1", status)
 	}
 }
 
 func TestClient_InvChatRoomMembers(t *testing.T) {
-	c, err := NewWCF(testAddr)
+	c, err := wcf.NewWCF(testAddr)
 	if err != nil {
-		t.Fatalf("NewWCF() error = %v", err)
+		t.Fatalf("wcf.NewWCF() error = %v", err)
 	}
 	defer c.Close()
 
-	contacts := c.GetContacts()
+	contacts, err := c.GetContacts()
+	if err != nil {
+		t.Fatalf("GetContacts() error = %v", err)
+	}
 	if len(contacts) < 2 {
 		t.Skip("Not enough contacts to test InvChatRoomMembers, skipping")
 	}
@@ -278,20 +424,26 @@ func TestClient_InvChatRoomMembers(t *testing.T) {
 	// 假设你有一个测试群，请替换为你的测试群 ID
 	roomID := "45959390469@chatroom" // 请替换为你的测试群 ID
 
-	status := c.InvChatRoomMembers(roomID, wxids)
+	status, err := c.InvChatRoomMembers(roomID, wxids)
+	if err != nil {
+		t.Fatalf("InvChatRoomMembers() error = %v", err)
+	}
 	if status != 1 {
 		t.Errorf("InvChatRoomMembers() = %v, want 1", status)
 	}
 }
 
 func TestClient_DelChatRoomMembers(t *testing.T) {
-	c, err := NewWCF(testAddr)
+	c, err := wcf.NewWCF(testAddr)
 	if err != nil {
-		t.Fatalf("NewWCF() error = %v", err)
+		t.Fatalf("wcf.NewWCF() error = %v", err)
 	}
 	defer c.Close()
 
-	contacts := c.GetContacts()
+	contacts, err := c.GetContacts()
+	if err != nil {
+		t.Fatalf("GetContacts() error = %v", err)
+	}
 	if len(contacts) < 2 {
 		t.Skip("Not enough contacts to test DelChatRoomMembers, skipping")
 	}
@@ -302,16 +454,19 @@ func TestClient_DelChatRoomMembers(t *testing.T) {
 	// 假设你有一个测试群，请替换为你的测试群 ID
 	roomID := "45959390469@chatroom" // 请替换为你的测试群 ID
 
-	status := c.DelChatRoomMembers(roomID, wxids)
+	status, err := c.DelChatRoomMembers(roomID, wxids)
+	if err != nil {
+		t.Fatalf("DelChatRoomMembers() error = %v", err)
+	}
 	if status != 1 {
 		t.Errorf("DelChatRoomMembers() = %v, want 1", status)
 	}
 }
 
 func TestClient_AcceptFriend(t *testing.T) {
-	c, err := NewWCF(testAddr)
+	c, err := wcf.NewWCF(testAddr)
 	if err != nil {
-		t.Fatalf("NewWCF() error = %v", err)
+		t.Fatalf("wcf.NewWCF() error = %v", err)
 	}
 	defer c.Close()
 
@@ -321,16 +476,19 @@ func TestClient_AcceptFriend(t *testing.T) {
 	//scene := int32(30)                                                                                                                                                                                                                                                                                                                     // 请根据实际情况修改
 	scene := int64(2421814416) // 请根据实际情况修改
 
-	status := c.AcceptFriend(v3, v4, scene)
+	status, err := c.AcceptFriend(v3, v4, scene)
+	if err != nil {
+		t.Fatalf("AcceptFriend() error = %v", err)
+	}
 	if status != 1 {
 		t.Errorf("AcceptFriend() = %v, want 1", status)
 	}
 }
 
 func TestClient_ReceiveTransfer(t *testing.T) {
-	c, err := NewWCF(testAddr)
+	c, err := wcf.NewWCF(testAddr)
 	if err != nil {
-		t.Fatalf("NewWCF() error = %v", err)
+		t.Fatalf("wcf.NewWCF() error = %v", err)
 	}
 	defer c.Close()
 
@@ -339,16 +497,19 @@ func TestClient_ReceiveTransfer(t *testing.T) {
 	tfid := "transfer_id"          // 请替换为实际的 transferid
 	taid := "transaction_id"       // 请替换为实际的 transactionid
 
-	status := c.ReceiveTransfer(wxid, tfid, taid)
+	status, err := c.ReceiveTransfer(wxid, tfid, taid)
+	if err != nil {
+		t.Fatalf("ReceiveTransfer() error = %v", err)
+	}
 	if status != 1 {
 		t.Errorf("ReceiveTransfer() = %v, want 1", status)
 	}
 }
 
 func TestClient_DecryptImage(t *testing.T) {
-	c, err := NewWCF(testAddr)
+	c, err := wcf.NewWCF(testAddr)
 	if err != nil {
-		t.Fatalf("NewWCF() error = %v", err)
+		t.Fatalf("wcf.NewWCF() error = %v", err)
 	}
 	defer c.Close()
 
@@ -356,7 +517,10 @@ func TestClient_DecryptImage(t *testing.T) {
 	src := "C:/Users/Administrator/Documents/WeChat Files/wxid_p5z4fuhnbdgs22/FileStorage/MsgAttach/84d8449549662bc200b18aabcf977f3a/Image/2025-02/010ff5751d7a461e3a98fe27fd5df1ab.dat" // 请替换为实际的加密图片路径
 	dst := "C:/Users/Administrator/Documents/WeChat Files/wxid_p5z4fuhnbdgs22/FileStorage/MsgAttach/84d8449549662bc200b18aabcf977f3a/Image/2025-02/test.jpg"                             // 请替换为实际的解密图片保存路径
 
-	decryptedPath := c.DecryptImage(src, dst)
+	decryptedPath, err := c.DecryptImage(src, dst)
+	if err != nil {
+		t.Fatalf("DecryptImage() error = %v", err)
+	}
 	if decryptedPath == "" {
 		t.Errorf("DecryptImage() returned empty string")
 	}
@@ -364,13 +528,16 @@ func TestClient_DecryptImage(t *testing.T) {
 }
 
 func TestClient_SendIMG(t *testing.T) {
-	c, err := NewWCF(testAddr)
+	c, err := wcf.NewWCF(testAddr)
 	if err != nil {
-		t.Fatalf("NewWCF() error = %v", err)
+		t.Fatalf("wcf.NewWCF() error = %v", err)
 	}
 	defer c.Close()
 
-	wxid := c.GetSelfWXID()
+	wxid, err := c.GetSelfWXID()
+	if err != nil {
+		t.Fatalf("GetSelfWXID() error = %v", err)
+	}
 	if wxid == "" {
 		t.Skip("SelfWXID is empty, skipping SendIMG test")
 	}
@@ -378,20 +545,26 @@ func TestClient_SendIMG(t *testing.T) {
 	// 请替换为实际的图片路径
 	imgPath := "C:\\images\\test.jpg" // 请替换为你的图片路径
 
-	status := c.SendIMG(imgPath, wxid)
+	status, err := c.SendIMG(imgPath, wxid)
+	if err != nil {
+		t.Fatalf("SendIMG() error = %v", err)
+	}
 	if status != 0 {
 		t.Errorf("SendIMG() = %v, want 0", status)
 	}
 }
 
 func TestClient_SendFile(t *testing.T) {
-	c, err := NewWCF(testAddr)
+	c, err := wcf.NewWCF(testAddr)
 	if err != nil {
-		t.Fatalf("NewWCF() error = %v", err)
+		t.Fatalf("wcf.NewWCF() error = %v", err)
 	}
 	defer c.Close()
 
-	wxid := c.GetSelfWXID()
+	wxid, err := c.GetSelfWXID()
+	if err != nil {
+		t.Fatalf("GetSelfWXID() error = %v", err)
+	}
 	if wxid == "" {
 		t.Skip("SelfWXID is empty, skipping SendFile test")
 	}
@@ -399,55 +572,70 @@ func TestClient_SendFile(t *testing.T) {
 	// 请替换为实际的文件路径
 	filePath := "path/to/your/file.txt" // 请替换为你的文件路径
 
-	status := c.SendFile(filePath, wxid)
+	status, err := c.SendFile(filePath, wxid)
+	if err != nil {
+		t.Fatalf("SendFile() error = %v", err)
+	}
 	if status != 0 {
 		t.Errorf("SendFile() = %v, want 0", status)
 	}
 }
 
 func TestClient_SendRichText(t *testing.T) {
-	c, err := NewWCF(testAddr)
+	c, err := wcf.NewWCF(testAddr)
 	if err != nil {
-		t.Fatalf("NewWCF() error = %v", err)
+		t.Fatalf("wcf.NewWCF() error = %v", err)
 	}
 	defer c.Close()
 
-	wxid := c.GetSelfWXID()
+	wxid, err := c.GetSelfWXID()
+	if err != nil {
+		t.Fatalf("GetSelfWXID() error = %v", err)
+	}
 	if wxid == "" {
 		t.Skip("SelfWXID is empty, skipping SendRichText test")
 	}
 
 	// 请根据实际情况修改参数
-	status := c.SendRichText("Name", "gh_account", "Title", "Digest", "https://example.com", "https://example.com/thumb.jpg", wxid)
+	status, err := c.SendRichText("Name", "gh_account", "Title", "Digest", "https://example.com", "https://example.com/thumb.jpg", wxid)
+	if err != nil {
+		t.Fatalf("SendRichText() error = %v", err)
+	}
 	if status != 1 {
 		t.Errorf("SendRichText() = %v, want 0", status)
 	}
 }
 
 func TestClient_SendXml(t *testing.T) {
-	c, err := NewWCF(testAddr)
+	c, err := wcf.NewWCF(testAddr)
 	if err != nil {
-		t.Fatalf("NewWCF() error = %v", err)
+		t.Fatalf("wcf.NewWCF() error = %v", err)
 	}
 	defer c.Close()
 
-	wxid := c.GetSelfWXID()
+	wxid, err := c.GetSelfWXID()
+	if err != nil {
+		t.Fatalf("GetSelfWXID() error = %v", err)
+	}
 	if wxid == "" {
 		t.Skip("SelfWXID is empty, skipping SendXml test")
 	}
 
 	// 请根据实际情况修改参数
 	xmlContent := "<appmsg appid=\\\"wx8dd6ecd81906fd84\\\" sdkver=\\\"0\\\">\\n\\t\\t<title>北宇治四重奏 第4番 トランペット (北宇治四重奏 第四章 小号)</title>\\n\\t\\t<des>安済知佳 - TVアニメ『響け！ユーフォニアム』キャラクターソング Vol.4</des>\\n\\t\\t<type>5</type>\\n\\t\\t<url>https://y.music.163.com/m/song?id=33789233&amp;fx-wxqd=c&amp;playerUIModeId=76001&amp;userid=3893734548&amp;app_version=9.2.30&amp;shareToken=38937345481736867627_86fabb98f86cce8cf26572d81c6cbd90&amp;fx-wechatnew=t1&amp;fx-wordtest=t4&amp;fx-listentest=t3&amp;PlayerStyles_SynchronousSharing=t3&amp;dlt=0846&amp;H5_DownloadVIPGift=</url>\\n\\t\\t<appattach>\\n\\t\\t\\t<cdnthumburl>3057020100044b304902010002045192ec6902032dd343020419161c6f020467867f32042464333834393239622d643931652d343433612d383935372d6433633761323063356639310204011408030201000405004c550700</cdnthumburl>\\n\\t\\t\\t<cdnthumbmd5>8ec20afe57f1e23f669f9fdc311bb27a</cdnthumbmd5>\\n\\t\\t\\t<cdnthumblength>8437</cdnthumblength>\\n\\t\\t\\t<cdnthumbwidth>135</cdnthumbwidth>\\n\\t\\t\\t<cdnthumbheight>135</cdnthumbheight>\\n\\t\\t\\t<cdnthumbaeskey>e3c87be21e79f2e956891618bf18f0be</cdnthumbaeskey>\\n\\t\\t\\t<aeskey>e3c87be21e79f2e956891618bf18f0be</aeskey>\\n\\t\\t\\t<encryver>0</encryver>\\n\\t\\t\\t<filekey>wxid_p5z4fuhnbdgs22_108_1736867633</filekey>\\n\\t\\t</appattach>\\n\\t\\t<md5>8ec20afe57f1e23f669f9fdc311bb27a</md5>\\n\\t\\t<statextstr>GhQKEnd4OGRkNmVjZDgxOTA2ZmQ4NA==</statextstr>\\n\\t</appmsg>" // 请替换为你的 XML 内容
-	status := c.SendXml("", xmlContent, wxid, 49)
+	status, err := c.SendXml("", xmlContent, wxid, 49)
+	if err != nil {
+		t.Fatalf("SendXml() error = %v", err)
+	}
 	if status != 0 {
 		t.Errorf("SendXml() = %v, want 0", status)
 	}
 }
 
 func TestClient_SendEmotion(t *testing.T) {
-	c, err := NewWCF(testAddr)
+	c, err := wcf.NewWCF(testAddr)
 	if err != nil {
-		t.Fatalf("NewWCF() error = %v", err)
+		t.Fatalf("wcf.NewWCF() error = %v", err)
 	}
 	defer c.Close()
 
@@ -459,16 +647,19 @@ func TestClient_SendEmotion(t *testing.T) {
 	// 请替换为实际的表情图片路径
 	emotionPath := "C:\\image\\test01.png" // 请替换为你的表情图片路径
 
-	status := c.SendEmotion(emotionPath, "wxid_jj4mhsji9tjk22")
+	status, err := c.SendEmotion(emotionPath, "wxid_jj4mhsji9tjk22")
+	if err != nil {
+		t.Fatalf("SendEmotion() error = %v", err)
+	}
 	if status != 0 {
 		t.Errorf("SendEmotion() = %v, want 0", status)
 	}
 }
 
 func TestClient_SendPat(t *testing.T) {
-	c, err := NewWCF(testAddr)
+	c, err := wcf.NewWCF(testAddr)
 	if err != nil {
-		t.Fatalf("NewWCF() error = %v", err)
+		t.Fatalf("wcf.NewWCF() error = %v", err)
 	}
 	defer c.Close()
 
@@ -476,16 +667,19 @@ func TestClient_SendPat(t *testing.T) {
 	roomID := "45959390469@chatroom" // 请替换为你的测试群 ID
 	wxid := "wxid_jj4mhsji9tjk22"    // 请替换为你要拍的群成员 wxid
 
-	status := c.SendPat(roomID, wxid)
+	status, err := c.SendPat(roomID, wxid)
+	if err != nil {
+		t.Fatalf("SendPat() error = %v", err)
+	}
 	if status != 1 {
 		t.Errorf("SendPat() = %v, want 1", status)
 	}
 }
 
 func TestClient_DownloadAttach(t *testing.T) {
-	c, err := NewWCF(testAddr)
+	c, err := wcf.NewWCF(testAddr)
 	if err != nil {
-		t.Fatalf("NewWCF() error = %v", err)
+		t.Fatalf("wcf.NewWCF() error = %v", err)
 	}
 	defer c.Close()
 
@@ -494,16 +688,19 @@ func TestClient_DownloadAttach(t *testing.T) {
 	thumb := "C:/Users/Administrator/Documents/WeChat Files/wxid_p5z4fuhnbdgs22/FileStorage/MsgAttach/84d8449549662bc200b18aabcf977f3a/Thumb/2025-02/40ec40baeaca9cf77a4cb60c77e3aef8_t.dat" // 请替换为实际的 thumb 路径
 	extra := "C:/Users/Administrator/Documents/WeChat Files/wxid_p5z4fuhnbdgs22/FileStorage/MsgAttach/84d8449549662bc200b18aabcf977f3a/Image/2025-02/ad29e8de84f5d7a0a2412bd1fedcbb62.dat"   // 请替换为实际的 extra 信息
 
-	status := c.DownloadAttach(msgID, thumb, extra)
+	status, err := c.DownloadAttach(msgID, thumb, extra)
+	if err != nil {
+		t.Fatalf("DownloadAttach() error = %v", err)
+	}
 	if status != 0 {
 		t.Errorf("DownloadAttach() = %v, want 0", status)
 	}
 }
 
 func TestClient_ForwardMsg(t *testing.T) {
-	c, err := NewWCF(testAddr)
+	c, err := wcf.NewWCF(testAddr)
 	if err != nil {
-		t.Fatalf("NewWCF() error = %v", err)
+		t.Fatalf("wcf.NewWCF() error = %v", err)
 	}
 	defer c.Close()
 
@@ -511,37 +708,46 @@ func TestClient_ForwardMsg(t *testing.T) {
 	msgID := uint64(574943826264397157) // 请替换为实际的消息 ID
 	receiver := "45959390469@chatroom"  // 请替换为实际的消息接收者 wxid
 
-	status := c.ForwardMsg(msgID, receiver)
+	status, err := c.ForwardMsg(msgID, receiver)
+	if err != nil {
+		t.Fatalf("ForwardMsg() error = %v", err)
+	}
 	if status != 1 {
 		t.Errorf("ForwardMsg() = %v, want 1", status)
 	}
 }
 
 func TestClient_GetContactByWxId(t *testing.T) {
-	c, err := NewWCF(testAddr)
+	c, err := wcf.NewWCF(testAddr)
 	if err != nil {
-		t.Fatalf("NewWCF() error = %v", err)
+		t.Fatalf("wcf.NewWCF() error = %v", err)
 	}
 	defer c.Close()
-	query := c.ExecDBQuery("MicroMsg.db", fmt.Sprintf("select * from ContactHeadImgUrl where usrName = '%s';", "wxid_pagpb98c6nj722"))
+	query, err := c.ExecDBQuery("MicroMsg.db", fmt.Sprintf("select * from ContactHeadImgUrl where usrName = '%s';", "wxid_pagpb98c6nj722"))
+	if err != nil {
+		t.Fatalf("ExecDBQuery() error = %v", err)
+	}
 	t.Log("query:", query)
 }
 
 func TestClient_GetContactByDB(t *testing.T) {
-	c, err := NewWCF(testAddr)
+	c, err := wcf.NewWCF(testAddr)
 	if err != nil {
-		t.Fatalf("NewWCF() error = %v", err)
+		t.Fatalf("wcf.NewWCF() error = %v", err)
 	}
 	defer c.Close()
 
-	contacts := c.ExecDBQuery("MicroMsg.db", "select * from Contact;")
+	contacts, err := c.ExecDBQuery("MicroMsg.db", "select * from Contact;")
+	if err != nil {
+		t.Fatalf("ExecDBQuery() error = %v", err)
+	}
 	for _, contact := range contacts {
 		t.Log("contact:", contact)
 		parseContact(t, contact)
 	}
 }
 
-func parseContact(t *testing.T, contact *DbRow) {
+func parseContact(t *testing.T, contact *wcf.DbRow) {
 	t.Logf("-------------------- New Contact --------------------")
 	for _, field := range contact.Fields {
 		if field.Column == "ExtraBuf" && field.Type == 4 {
@@ -603,3 +809,426 @@ func parseExtraBuf(extraBuf []byte) (map[string]interface{}, error) {
 
 	return result, nil
 }
+
+func TestClient_ErrStatus(t *testing.T) {
+	srv, err := wcftest.NewServer()
+	if err != nil {
+		t.Fatalf("wcftest.NewServer() error = %v", err)
+	}
+	defer srv.Close()
+	c, err := wcf.NewWCF(srv.Addr())
+	if err != nil {
+		t.Fatalf("wcf.NewWCF() error = %v", err)
+	}
+	defer c.Close()
+
+	srv.SetStatus(wcf.Functions_FUNC_SEND_TXT, -2)
+	status, err := c.SendTxt("hi", "wxid_pagpb98c6nj722", nil)
+	var statusErr *wcf.ErrStatus
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("SendTxt() error = %v, want *wcf.ErrStatus", err)
+	}
+	if status != -2 || statusErr.Code != -2 || statusErr.Func != wcf.Functions_FUNC_SEND_TXT {
+		t.Errorf("SendTxt() = %d, %+v, want -2 from FUNC_SEND_TXT", status, statusErr)
+	}
+}
+
+func TestClient_ErrTransport(t *testing.T) {
+	srv, err := wcftest.NewServer()
+	if err != nil {
+		t.Fatalf("wcftest.NewServer() error = %v", err)
+	}
+	defer srv.Close()
+	c, err := wcf.NewWCF(srv.Addr())
+	if err != nil {
+		t.Fatalf("wcf.NewWCF() error = %v", err)
+	}
+	_ = c.Close()
+
+	if _, err = c.GetContacts(); !errors.Is(err, wcf.ErrTransport) {
+		t.Errorf("GetContacts() after Close error = %v, want wcf.ErrTransport", err)
+	}
+}
+
+// newSlowClient 连接一个 EXEC_DB_QUERY 应答延迟 delay 的模拟服务端
+func newSlowClient(t *testing.T, delay time.Duration) *wcf.Client {
+	t.Helper()
+	srv, err := wcftest.NewServer()
+	if err != nil {
+		t.Fatalf("wcftest.NewServer() error = %v", err)
+	}
+	t.Cleanup(func() { _ = srv.Close() })
+	srv.SetUserInfo(&wcf.UserInfo{Wxid: "wxid_p5z4fuhnbdgs22"})
+	srv.Handle(wcf.Functions_FUNC_EXEC_DB_QUERY, func(req *wcf.Request) *wcf.Response {
+		time.Sleep(delay)
+		return nil
+	})
+	c, err := wcf.NewWCF(srv.Addr())
+	if err != nil {
+		t.Fatalf("wcf.NewWCF() error = %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func TestClient_ExecDBQueryCtxDeadline(t *testing.T) {
+	c := newSlowClient(t, 300*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := c.ExecDBQueryCtx(ctx, "MicroMsg.db", "select * from Contact;")
+	if !errors.Is(err, wcf.ErrTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("ExecDBQueryCtx() error = %v, want ErrTimeout", err)
+	}
+
+	// 被放弃调用的迟到应答不能串到下一次调用
+	wxid, err := c.GetSelfWXID()
+	if err != nil || wxid != "wxid_p5z4fuhnbdgs22" {
+		t.Errorf("GetSelfWXID() after abandoned call = %q, %v", wxid, err)
+	}
+}
+
+func TestClient_ExecDBQueryCtxCancel(t *testing.T) {
+	c := newSlowClient(t, 300*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := c.ExecDBQueryCtx(ctx, "MicroMsg.db", "select * from Contact;")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("ExecDBQueryCtx() error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 250*time.Millisecond {
+		t.Errorf("ExecDBQueryCtx() returned after %v, want prompt cancellation", elapsed)
+	}
+}
+
+func TestClient_SetTimeout(t *testing.T) {
+	c := newSlowClient(t, 300*time.Millisecond)
+
+	c.SetTimeout(50 * time.Millisecond)
+	if _, err := c.ExecDBQuery("MicroMsg.db", "select * from Contact;"); !errors.Is(err, wcf.ErrTimeout) {
+		t.Fatalf("ExecDBQuery() error = %v, want ErrTimeout", err)
+	}
+	c.SetTimeout(time.Second)
+	if _, err := c.ExecDBQuery("MicroMsg.db", "select * from Contact;"); err != nil {
+		t.Errorf("ExecDBQuery() error = %v", err)
+	}
+}
+
+// TestClient_CtxWhileQueued 排队等待其他调用时同样受 ctx 控制，SetTimeout 不等待进行中的调用
+func TestClient_CtxWhileQueued(t *testing.T) {
+	c := newSlowClient(t, time.Second)
+	started := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		close(started)
+		_, _ = c.ExecDBQuery("MicroMsg.db", "select * from Contact;")
+	}()
+	<-started
+	time.Sleep(50 * time.Millisecond) // 等待慢调用取得执行权
+
+	start := time.Now()
+	c.SetTimeout(30 * time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := c.IsLoginCtx(ctx); !errors.Is(err, wcf.ErrTimeout) {
+		t.Errorf("IsLoginCtx() error = %v, want ErrTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("IsLoginCtx() returned after %v, want it to honour the deadline while queued", elapsed)
+	}
+	<-done
+	if ok, err := c.IsLogin(); err != nil || !ok {
+		t.Errorf("IsLogin() after queued timeout = %v, %v", ok, err)
+	}
+}
+
+// TestClient_ConcurrentCalls 大量并发的混合调用不能读到彼此的应答
+func TestClient_ConcurrentCalls(t *testing.T) {
+	srv, err := wcftest.NewServer()
+	if err != nil {
+		t.Fatalf("wcftest.NewServer() error = %v", err)
+	}
+	defer srv.Close()
+	seedFakeServer(srv)
+	c, err := wcf.NewWCF(srv.Addr())
+	if err != nil {
+		t.Fatalf("wcf.NewWCF() error = %v", err)
+	}
+	defer c.Close()
+
+	calls := []func() error{
+		func() error {
+			wxid, err := c.GetSelfWXID()
+			if err == nil && wxid != "wxid_p5z4fuhnbdgs22" {
+				err = fmt.Errorf("GetSelfWXID() = %q", wxid)
+			}
+			return err
+		},
+		func() error {
+			contacts, err := c.GetContacts()
+			if err == nil && len(contacts) != 3 {
+				err = fmt.Errorf("GetContacts() len = %d", len(contacts))
+			}
+			return err
+		},
+		func() error {
+			rows, err := c.ExecDBQuery("MicroMsg.db", "select * from Contact;")
+			if err == nil && (len(rows) != 1 || string(rows[0].GetFields()[0].GetContent()) != "wxid_pagpb98c6nj722") {
+				err = fmt.Errorf("ExecDBQuery() = %v", rows)
+			}
+			return err
+		},
+		func() error {
+			_, err := c.SendTxt("hi", "wxid_pagpb98c6nj722", nil)
+			return err
+		},
+		func() error {
+			ok, err := c.IsLogin()
+			if err == nil && !ok {
+				err = fmt.Errorf("IsLogin() = false")
+			}
+			return err
+		},
+		func() error {
+			ui, err := c.GetUserInfo()
+			if err == nil && ui.GetName() != "bot" {
+				err = fmt.Errorf("GetUserInfo() = %v", ui)
+			}
+			return err
+		},
+	}
+
+	const n = 300
+	var wg sync.WaitGroup
+	errCh := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(call func() error) {
+			defer wg.Done()
+			if err := call(); err != nil {
+				errCh <- err
+			}
+		}(calls[i%len(calls)])
+	}
+	wg.Wait()
+	close(errCh)
+	for err := range errCh {
+		t.Error(err)
+	}
+}
+
+func TestClient_MismatchNotStale(t *testing.T) {
+	srv, err := wcftest.NewServer()
+	if err != nil {
+		t.Fatalf("wcftest.NewServer() error = %v", err)
+	}
+	defer srv.Close()
+	seedFakeServer(srv)
+	srv.Handle(wcf.Functions_FUNC_IS_LOGIN, func(req *wcf.Request) *wcf.Response {
+		return &wcf.Response{Func: wcf.Functions_FUNC_GET_SELF_WXID, Msg: &wcf.Response_Status{Status: 1}} // 唯一的应答带着错误的 Func
+	})
+	c, err := wcf.NewWCF(srv.Addr())
+	if err != nil {
+		t.Fatalf("wcf.NewWCF() error = %v", err)
+	}
+	defer c.Close()
+
+	if _, err = c.IsLogin(); !errors.Is(err, wcf.ErrMismatch) {
+		t.Fatalf("IsLogin() error = %v, want ErrMismatch", err)
+	}
+	for i := 0; i < 3; i++ { // 之后的调用不应把自己的应答当作过期应答丢弃
+		wxid, err := c.GetSelfWXIDCtx(context.Background())
+		if err != nil || wxid != "wxid_p5z4fuhnbdgs22" {
+			t.Fatalf("GetSelfWXID() after mismatch = %q, %v", wxid, err)
+		}
+	}
+}
+
+// startPoolClient 以指定协程池配置开始接收模拟服务端推送的消息
+func startPoolClient(t *testing.T, cfg wcf.PoolConfig, f wcf.MsgHandler) (*wcf.Client, *wcftest.Server) {
+	t.Helper()
+	srv, err := wcftest.NewServer()
+	if err != nil {
+		t.Fatalf("wcftest.NewServer() error = %v", err)
+	}
+	t.Cleanup(func() { _ = srv.Close() })
+	c, err := wcf.NewWCF(srv.Addr())
+	if err != nil {
+		t.Fatalf("wcf.NewWCF() error = %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	if err = c.SetPoolConfig(cfg); err != nil {
+		t.Fatalf("SetPoolConfig() error = %v", err)
+	}
+	if _, err = c.EnableRecvTxt(); err != nil {
+		t.Fatalf("EnableRecvTxt() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	ready := make(chan struct{})
+	go func() { _ = c.OnMSGReady(ctx, f, func() { close(ready) }) }()
+	select {
+	case <-ready:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("OnMSGReady() not ready")
+	}
+	return c, srv
+}
+
+// waitPoolStats 等待协程池指标满足条件
+func waitPoolStats(t *testing.T, c *wcf.Client, cond func(wcf.PoolStats) bool) wcf.PoolStats {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		stats := c.PoolStats()
+		if cond(stats) {
+			return stats
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("pool stats not reached, got %+v", stats)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestClient_PoolBoundedWorkers(t *testing.T) {
+	var running, maxRunning int32
+	var mu sync.Mutex
+	c, srv := startPoolClient(t, wcf.PoolConfig{Workers: 2, QueueSize: 64, Overflow: wcf.OverflowBlock}, func(msg *wcf.WxMsg) error {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	})
+	for i := 1; i <= 20; i++ {
+		if err := srv.Push(&wcf.WxMsg{Id: uint64(i), Type: 1, Content: "hello"}); err != nil {
+			t.Fatalf("Push() error = %v", err)
+		}
+	}
+	stats := waitPoolStats(t, c, func(s wcf.PoolStats) bool { return s.Handled == 20 })
+	if stats.Dropped != 0 || stats.Received != 20 || stats.Enqueued != 20 || stats.Workers != 2 {
+		t.Errorf("PoolStats() = %+v", stats)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if maxRunning > 2 {
+		t.Errorf("max concurrent handlers = %d, want <= 2", maxRunning)
+	}
+}
+
+func TestClient_PoolOverflow(t *testing.T) {
+	tests := []struct {
+		policy   wcf.OverflowPolicy
+		wantLast bool // 最后一条消息是否被处理
+	}{
+		{wcf.OverflowDropNewest, false},
+		{wcf.OverflowDropOldest, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			gate := make(chan struct{})
+			var mu sync.Mutex
+			handled := make(map[uint64]bool)
+			c, srv := startPoolClient(t, wcf.PoolConfig{Workers: 1, QueueSize: 2, Overflow: tt.policy}, func(msg *wcf.WxMsg) error {
+				<-gate
+				mu.Lock()
+				handled[msg.Id] = true
+				mu.Unlock()
+				return nil
+			})
+			const total = 10
+			for i := 1; i <= total; i++ {
+				if err := srv.Push(&wcf.WxMsg{Id: uint64(i), Type: 1, Content: "hello"}); err != nil {
+					t.Fatalf("Push() error = %v", err)
+				}
+			}
+			stats := waitPoolStats(t, c, func(s wcf.PoolStats) bool { return s.Received == total && s.Busy == 1 })
+			if stats.QueueDepth < 1 || stats.Dropped < total-3 || uint64(stats.QueueDepth)+stats.Dropped+1 != total {
+				t.Errorf("PoolStats() = %+v, want a full queue and >= %d dropped", stats, total-3)
+			}
+			close(gate)
+			waitPoolStats(t, c, func(s wcf.PoolStats) bool { return s.Handled == total-s.Dropped })
+			mu.Lock()
+			defer mu.Unlock()
+			if handled[total] != tt.wantLast {
+				t.Errorf("last message handled = %v, want %v (handled %v)", handled[total], tt.wantLast, handled)
+			}
+		})
+	}
+}
+
+func TestClient_PoolOrdered(t *testing.T) {
+	var mu sync.Mutex
+	got := make(map[string][]uint64)
+	c, srv := startPoolClient(t, wcf.PoolConfig{Workers: 4, QueueSize: 64, Overflow: wcf.OverflowBlock, Ordered: true}, func(msg *wcf.WxMsg) error {
+		time.Sleep(time.Duration(msg.Id%3) * time.Millisecond) // 处理耗时不同，无序处理时会打乱顺序
+		mu.Lock()
+		got[msg.Roomid] = append(got[msg.Roomid], msg.Id)
+		mu.Unlock()
+		return nil
+	})
+	rooms := []string{"1@chatroom", "2@chatroom", "3@chatroom"}
+	const total = 60
+	for i := 1; i <= total; i++ {
+		msg := &wcf.WxMsg{Id: uint64(i), Ts: uint32(1736867600 + i), Type: 1, IsGroup: true, Roomid: rooms[i%len(rooms)], Sender: "wxid_friend"}
+		if err := srv.Push(msg); err != nil {
+			t.Fatalf("Push() error = %v", err)
+		}
+	}
+	stats := waitPoolStats(t, c, func(s wcf.PoolStats) bool { return s.Handled == total })
+	if stats.OutOfOrder != 0 || stats.Duplicated != 0 {
+		t.Errorf("PoolStats() = %+v", stats)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for room, ids := range got {
+		for i := 1; i < len(ids); i++ {
+			if ids[i] < ids[i-1] {
+				t.Errorf("room %s handled out of order: %v", room, ids)
+				break
+			}
+		}
+	}
+}
+
+func TestClient_PoolOrderedDetect(t *testing.T) {
+	var mu sync.Mutex
+	var got []uint64
+	c, srv := startPoolClient(t, wcf.PoolConfig{Workers: 2, QueueSize: 16, Overflow: wcf.OverflowBlock, Ordered: true}, func(msg *wcf.WxMsg) error {
+		mu.Lock()
+		got = append(got, msg.Id)
+		mu.Unlock()
+		return nil
+	})
+	msgs := []*wcf.WxMsg{
+		{Id: 11, Ts: 1736867610, Sender: "wxid_friend"},
+		{Id: 12, Ts: 1736867620, Sender: "wxid_friend"},
+		{Id: 12, Ts: 1736867620, Sender: "wxid_friend"}, // 重复
+		{Id: 10, Ts: 1736867600, Sender: "wxid_friend"}, // 乱序
+		{Id: 13, Ts: 1736867630, Sender: "wxid_friend"},
+	}
+	for _, msg := range msgs {
+		if err := srv.Push(msg); err != nil {
+			t.Fatalf("Push() error = %v", err)
+		}
+	}
+	stats := waitPoolStats(t, c, func(s wcf.PoolStats) bool { return s.Handled+s.Duplicated == uint64(len(msgs)) })
+	if stats.OutOfOrder != 1 || stats.Duplicated != 1 {
+		t.Errorf("PoolStats() = %+v, want 1 out of order and 1 duplicated", stats)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if fmt.Sprint(got) != "[11 12 10 13]" {
+		t.Errorf("handled = %v, want [11 12 10 13]", got)
+	}
+}
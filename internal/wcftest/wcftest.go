@@ -0,0 +1,442 @@
+// Package wcftest
+// @Author Clover
+// @Data 2026/10/16 下午2:10:00
+// @Desc 进程内的 WCF RPC 模拟服务端，无需微信与 sdk.dll 即可离线测试
+package wcftest
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Clov614/wcf-rpc-sdk/internal/wcf"
+	"go.nanomsg.org/mangos/v3"
+	"go.nanomsg.org/mangos/v3/protocol"
+	"go.nanomsg.org/mangos/v3/protocol/pair1"
+	_ "go.nanomsg.org/mangos/v3/transport/all"
+	"google.golang.org/protobuf/proto"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HandlerFunc 自定义某个 Functions 的应答，返回 nil 时回落到默认实现
+type HandlerFunc func(req *wcf.Request) *wcf.Response
+
+// defaultStatus 与真实 WCF 服务端保持一致的默认状态码
+var defaultStatus = map[wcf.Functions]int32{
+	wcf.Functions_FUNC_SEND_TXT:         0,
+	wcf.Functions_FUNC_SEND_IMG:         0,
+	wcf.Functions_FUNC_SEND_FILE:        0,
+	wcf.Functions_FUNC_SEND_XML:         0,
+	wcf.Functions_FUNC_SEND_EMOTION:     0,
+	wcf.Functions_FUNC_SEND_RICH_TXT:    1,
+	wcf.Functions_FUNC_SEND_PAT_MSG:     1,
+	wcf.Functions_FUNC_FORWARD_MSG:      1,
+	wcf.Functions_FUNC_ENABLE_RECV_TXT:  0,
+	wcf.Functions_FUNC_DISABLE_RECV_TXT: 0,
+	wcf.Functions_FUNC_ACCEPT_FRIEND:    1,
+	wcf.Functions_FUNC_RECV_TRANSFER:    1,
+	wcf.Functions_FUNC_REFRESH_PYQ:      1,
+	wcf.Functions_FUNC_DOWNLOAD_ATTACH:  0,
+	wcf.Functions_FUNC_REVOKE_MSG:       1,
+	wcf.Functions_FUNC_ADD_ROOM_MEMBERS: 1,
+	wcf.Functions_FUNC_DEL_ROOM_MEMBERS: 1,
+	wcf.Functions_FUNC_INV_ROOM_MEMBERS: 1,
+}
+
+// Server 模拟服务端，在 port 上监听命令，在 port+1 上推送消息（与 wcf.addPort 对应）
+type Server struct {
+	addr    string
+	cmdSock protocol.Socket
+	msgSock protocol.Socket
+	cmdPeer peerTracker
+	msgPeer peerTracker
+
+	mu          sync.Mutex
+	loggedIn    bool
+	userInfo    *wcf.UserInfo
+	contacts    []*wcf.RpcContact
+	msgTypes    map[int32]string
+	dbNames     []string
+	dbTables    map[string][]*wcf.DbTable
+	queries     map[string][]*wcf.DbRow // db + sql -> rows
+	statuses    map[wcf.Functions]int32
+	handlers    map[wcf.Functions]HandlerFunc
+	requests    []*wcf.Request
+	recvEnabled bool
+
+	wg sync.WaitGroup
+}
+
+// NewServer 在本地随机端口启动模拟服务端
+func NewServer() (*Server, error) {
+	s := &Server{
+		loggedIn: true,
+		msgTypes: make(map[int32]string),
+		dbTables: make(map[string][]*wcf.DbTable),
+		queries:  make(map[string][]*wcf.DbRow),
+		statuses: make(map[wcf.Functions]int32),
+		handlers: make(map[wcf.Functions]HandlerFunc),
+	}
+	var err error
+	if s.cmdSock, err = pair1.NewSocket(); err != nil {
+		return nil, fmt.Errorf("wcftest: new cmd socket: %w", err)
+	}
+	if s.msgSock, err = pair1.NewSocket(); err != nil {
+		_ = s.cmdSock.Close()
+		return nil, fmt.Errorf("wcftest: new msg socket: %w", err)
+	}
+	// pair1 只允许一个对端，旧连接尚未断开时新连接会被拒绝并丢失已发出的请求，
+	// 这里改为新连接顶替旧连接，便于测试中反复创建客户端
+	s.cmdSock.SetPipeEventHook(s.cmdPeer.hook)
+	s.msgSock.SetPipeEventHook(s.msgPeer.hook)
+	if err = s.listen(); err != nil {
+		_ = s.cmdSock.Close()
+		_ = s.msgSock.Close()
+		return nil, err
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// listen 寻找一对连续的空闲端口并监听
+func (s *Server) listen() error {
+	var lastErr error
+	for i := 0; i < 10; i++ {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return fmt.Errorf("wcftest: pick port: %w", err)
+		}
+		port := l.Addr().(*net.TCPAddr).Port
+		_ = l.Close()
+
+		cmdAddr := fmt.Sprintf("tcp://127.0.0.1:%d", port)
+		cmdListener, err := s.cmdSock.NewListener(cmdAddr, nil)
+		if err == nil {
+			err = cmdListener.Listen()
+		}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		err = s.msgSock.Listen(fmt.Sprintf("tcp://127.0.0.1:%d", port+1))
+		if err != nil {
+			_ = cmdListener.Close()
+			lastErr = err
+			continue
+		}
+		s.addr = cmdAddr
+		return nil
+	}
+	return fmt.Errorf("wcftest: listen: %w", lastErr)
+}
+
+const attachWait = 2 * time.Second // Disconnect、Close 等待客户端连接接入的时间
+
+// peerTracker 记录当前对端，新连接接入时关闭旧连接
+type peerTracker struct {
+	mu      sync.Mutex
+	peer    mangos.Pipe
+	pending bool // 客户端即将拨号，服务端尚未接入
+}
+
+func (pt *peerTracker) hook(ev mangos.PipeEvent, p mangos.Pipe) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	switch ev {
+	case mangos.PipeEventAttaching:
+		if pt.peer != nil {
+			_ = pt.peer.Close()
+		}
+		pt.peer = p
+		pt.pending = false
+	case mangos.PipeEventDetached:
+		if pt.peer == p {
+			pt.peer = nil
+		}
+	}
+}
+
+// await 等待 cond 成立，最多等待 attachWait，返回时持有 pt.mu
+func (pt *peerTracker) await(cond func() bool) {
+	deadline := time.Now().Add(attachWait)
+	pt.mu.Lock()
+	for !cond() && time.Now().Before(deadline) {
+		pt.mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		pt.mu.Lock()
+	}
+}
+
+// expect 标记客户端即将拨号
+func (pt *peerTracker) expect() {
+	pt.mu.Lock()
+	pt.pending = true
+	pt.mu.Unlock()
+}
+
+// settle 等待 expect 之后的连接接入
+func (pt *peerTracker) settle() {
+	pt.await(func() bool { return !pt.pending })
+	pt.mu.Unlock()
+}
+
+// drop 主动断开当前对端，客户端拨号返回时服务端可能尚未接入该连接，最多等待 attachWait
+func (pt *peerTracker) drop() {
+	pt.await(func() bool { return pt.peer != nil })
+	peer := pt.peer
+	pt.peer = nil
+	pt.mu.Unlock()
+	if peer != nil {
+		_ = peer.Close()
+	}
+}
+
+// Disconnect 断开当前客户端的两个连接，并重置消息接收状态，模拟微信重启或 RPC 主机掉线
+func (s *Server) Disconnect() {
+	s.mu.Lock()
+	s.recvEnabled = false
+	s.mu.Unlock()
+	s.msgPeer.drop()
+	s.cmdPeer.drop()
+}
+
+// Addr 命令端口地址，可直接传给 wcf.NewWCF 或设置到 TCP_ADDR
+func (s *Server) Addr() string {
+	return s.addr
+}
+
+// Close 关闭模拟服务端
+// 客户端拨号返回时服务端可能仍在握手，此时关闭监听会与 mangos 的握手协程竞争，因此先等待消息连接接入
+func (s *Server) Close() error {
+	s.msgPeer.settle()
+	err := s.cmdSock.Close()
+	if msgErr := s.msgSock.Close(); err == nil {
+		err = msgErr
+	}
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		raw, err := s.cmdSock.Recv()
+		if err != nil {
+			if errors.Is(err, mangos.ErrClosed) {
+				return
+			}
+			continue
+		}
+		req := &wcf.Request{}
+		if err = proto.Unmarshal(raw, req); err != nil {
+			continue
+		}
+		resp := s.handle(req)
+		if resp.Func == wcf.Functions_FUNC_RESERVED { // 自定义应答可指定错误的 Func 以模拟异常服务端
+			resp.Func = req.Func
+		}
+		data, err := proto.Marshal(resp)
+		if err != nil {
+			continue
+		}
+		if err = s.cmdSock.Send(data); errors.Is(err, mangos.ErrClosed) {
+			return
+		}
+	}
+}
+
+func (s *Server) handle(req *wcf.Request) *wcf.Response {
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	h := s.handlers[req.Func]
+	s.mu.Unlock()
+	if h != nil { // 自定义应答优先
+		if resp := h(req); resp != nil {
+			return resp
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch req.Func {
+	case wcf.Functions_FUNC_IS_LOGIN:
+		var status int32
+		if s.loggedIn {
+			status = 1
+		}
+		return Status(status)
+	case wcf.Functions_FUNC_GET_SELF_WXID:
+		return Str(s.userInfo.GetWxid())
+	case wcf.Functions_FUNC_GET_USER_INFO:
+		if s.userInfo == nil {
+			return &wcf.Response{}
+		}
+		return &wcf.Response{Msg: &wcf.Response_Ui{Ui: s.userInfo}}
+	case wcf.Functions_FUNC_GET_MSG_TYPES:
+		return &wcf.Response{Msg: &wcf.Response_Types{Types: &wcf.MsgTypes{Types: s.msgTypes}}}
+	case wcf.Functions_FUNC_GET_CONTACTS:
+		return &wcf.Response{Msg: &wcf.Response_Contacts{Contacts: &wcf.RpcContacts{Contacts: s.contacts}}}
+	case wcf.Functions_FUNC_GET_CONTACT_INFO:
+		var found []*wcf.RpcContact
+		for _, ct := range s.contacts {
+			if ct.Wxid == req.GetStr() {
+				found = append(found, ct)
+			}
+		}
+		return &wcf.Response{Msg: &wcf.Response_Contacts{Contacts: &wcf.RpcContacts{Contacts: found}}}
+	case wcf.Functions_FUNC_GET_DB_NAMES:
+		return &wcf.Response{Msg: &wcf.Response_Dbs{Dbs: &wcf.DbNames{Names: s.dbNames}}}
+	case wcf.Functions_FUNC_GET_DB_TABLES:
+		return &wcf.Response{Msg: &wcf.Response_Tables{Tables: &wcf.DbTables{Tables: s.dbTables[req.GetStr()]}}}
+	case wcf.Functions_FUNC_EXEC_DB_QUERY:
+		q := req.GetQuery()
+		return &wcf.Response{Msg: &wcf.Response_Rows{Rows: &wcf.DbRows{Rows: s.queries[queryKey(q.GetDb(), q.GetSql())]}}}
+	case wcf.Functions_FUNC_DECRYPT_IMAGE:
+		return Str(req.GetDec().GetDst())
+	case wcf.Functions_FUNC_EXEC_OCR: // SetStatus 设置的是识别状态，非 0 表示未就绪
+		return &wcf.Response{Msg: &wcf.Response_Ocr{Ocr: &wcf.OcrMsg{Status: s.status(req.Func)}}}
+	case wcf.Functions_FUNC_ENABLE_RECV_TXT:
+		s.recvEnabled = true
+		if s.status(req.Func) == 0 { // 开启成功后客户端随即拨号消息端口
+			s.msgPeer.expect()
+		}
+	case wcf.Functions_FUNC_DISABLE_RECV_TXT:
+		s.recvEnabled = false
+	}
+	return Status(s.status(req.Func))
+}
+
+func (s *Server) status(fun wcf.Functions) int32 {
+	if status, ok := s.statuses[fun]; ok {
+		return status
+	}
+	return defaultStatus[fun]
+}
+
+// SetLogin 设置登录状态（默认已登录）
+func (s *Server) SetLogin(loggedIn bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loggedIn = loggedIn
+}
+
+// SetUserInfo 设置登录账号信息，同时决定 GET_SELF_WXID 的返回
+func (s *Server) SetUserInfo(ui *wcf.UserInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.userInfo = ui
+}
+
+// SetContacts 设置通讯录
+func (s *Server) SetContacts(contacts ...*wcf.RpcContact) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.contacts = contacts
+}
+
+// SetMsgTypes 设置消息类型表
+func (s *Server) SetMsgTypes(types map[int32]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.msgTypes = types
+}
+
+// SetDBTables 设置数据库及其表，首次出现的数据库会加入数据库名列表
+func (s *Server) SetDBTables(db string, tables ...*wcf.DbTable) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.dbTables[db]; !ok {
+		s.dbNames = append(s.dbNames, db)
+	}
+	s.dbTables[db] = tables
+}
+
+// SetQuery 设置某条 sql 的查询结果（按 db 与 sql 原文精确匹配）
+func (s *Server) SetQuery(db, sql string, rows ...*wcf.DbRow) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queries[queryKey(db, sql)] = rows
+}
+
+// SetStatus 覆盖某个 Functions 返回的状态码
+func (s *Server) SetStatus(fun wcf.Functions, status int32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statuses[fun] = status
+}
+
+// Handle 自定义某个 Functions 的应答
+func (s *Server) Handle(fun wcf.Functions, h HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[fun] = h
+}
+
+// Push 通过消息端口推送一条微信消息，客户端未连接时会先排队
+func (s *Server) Push(msg *wcf.WxMsg) error {
+	data, err := proto.Marshal(&wcf.Response{
+		Func: wcf.Functions_FUNC_ENABLE_RECV_TXT,
+		Msg:  &wcf.Response_Wxmsg{Wxmsg: msg},
+	})
+	if err != nil {
+		return fmt.Errorf("wcftest: marshal wxmsg: %w", err)
+	}
+	return s.msgSock.Send(data)
+}
+
+// RecvEnabled 客户端是否已开启消息接收
+func (s *Server) RecvEnabled() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.recvEnabled
+}
+
+// Requests 返回已收到的全部请求（按到达顺序）
+func (s *Server) Requests() []*wcf.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*wcf.Request(nil), s.requests...)
+}
+
+// RequestsOf 返回某个 Functions 已收到的请求
+func (s *Server) RequestsOf(fun wcf.Functions) []*wcf.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var reqs []*wcf.Request
+	for _, req := range s.requests {
+		if req.Func == fun {
+			reqs = append(reqs, req)
+		}
+	}
+	return reqs
+}
+
+// Status 构造状态码应答
+func Status(status int32) *wcf.Response {
+	return &wcf.Response{Msg: &wcf.Response_Status{Status: status}}
+}
+
+// Str 构造字符串应答
+func Str(str string) *wcf.Response {
+	return &wcf.Response{Msg: &wcf.Response_Str{Str: str}}
+}
+
+// Row 以 "列名, 内容, 列名, 内容..." 的形式构造一行文本数据
+func Row(kv ...string) *wcf.DbRow {
+	row := &wcf.DbRow{}
+	for i := 0; i+1 < len(kv); i += 2 {
+		row.Fields = append(row.Fields, &wcf.DbField{Type: 3, Column: kv[i], Content: []byte(kv[i+1])})
+	}
+	return row
+}
+
+// BlobRow 构造只含一个二进制字段的行
+func BlobRow(column string, content []byte) *wcf.DbRow {
+	return &wcf.DbRow{Fields: []*wcf.DbField{{Type: 4, Column: column, Content: content}}}
+}
+
+func queryKey(db, sql string) string {
+	return db + "\x00" + strings.TrimSpace(sql)
+}
@@ -0,0 +1,129 @@
+// Package wcf_rpc_sdk
+// @Author Clover
+// @Data 2026/10/17 上午3:00:00
+// @Desc 链接（公众号文章、分享链接）消息解析与发送
+package wcf_rpc_sdk
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"github.com/Clov614/wcf-rpc-sdk/internal/utils/imgutil"
+)
+
+const appMsgTypeLink = 5 // appmsg 中链接消息的 type
+
+// LinkMsg 链接消息
+type LinkMsg struct {
+	Title          string `json:"title"`
+	Desc           string `json:"desc,omitempty"`
+	URL            string `json:"url"`
+	ThumbURL       string `json:"thumb_url,omitempty"`       // 缩略图链接
+	SourceName     string `json:"source_name,omitempty"`     // 来源名称，如公众号名称
+	SourceUsername string `json:"source_username,omitempty"` // 来源 wxid，如公众号 gh_ 开头的 id
+}
+
+// linkXml 链接消息的 appmsg，解析与发送共用
+type linkXml struct {
+	XMLName xml.Name `xml:"msg"`
+	AppMsg  struct {
+		AppId             string `xml:"appid,attr"`
+		SdkVer            string `xml:"sdkver,attr"`
+		Title             string `xml:"title"`
+		Des               string `xml:"des"`
+		Type              int    `xml:"type"`
+		URL               string `xml:"url"`
+		ThumbURL          string `xml:"thumburl"`
+		SourceUsername    string `xml:"sourceusername"`
+		SourceDisplayName string `xml:"sourcedisplayname"`
+	} `xml:"appmsg"`
+	AppName string `xml:"appinfo>appname,omitempty"`
+}
+
+// parseLinkMsg 解析 appmsg type 为 5 的链接消息，其他消息返回 nil
+func parseLinkMsg(content string) (*LinkMsg, error) {
+	var lx linkXml
+	if err := xml.Unmarshal([]byte(content), &lx); err != nil {
+		return nil, fmt.Errorf("xml.Unmarshal link: %w", err)
+	}
+	a := lx.AppMsg
+	if a.Type != appMsgTypeLink {
+		return nil, nil
+	}
+	link := &LinkMsg{
+		Title:          a.Title,
+		Desc:           a.Des,
+		URL:            a.URL,
+		ThumbURL:       a.ThumbURL,
+		SourceName:     a.SourceDisplayName,
+		SourceUsername: a.SourceUsername,
+	}
+	if link.SourceName == "" {
+		link.SourceName = lx.AppName
+	}
+	return link, nil
+}
+
+func (l LinkMsg) xml() (string, error) {
+	var lx linkXml
+	lx.AppMsg.SdkVer = "0"
+	lx.AppMsg.Title = l.Title
+	lx.AppMsg.Des = l.Desc
+	lx.AppMsg.Type = appMsgTypeLink
+	lx.AppMsg.URL = l.URL
+	lx.AppMsg.ThumbURL = l.ThumbURL
+	lx.AppMsg.SourceUsername = l.SourceUsername
+	lx.AppMsg.SourceDisplayName = l.SourceName
+	data, err := xml.Marshal(lx)
+	if err != nil {
+		return "", fmt.Errorf("xml.Marshal link: %w", err)
+	}
+	return xml.Header + string(data), nil
+}
+
+// SendLink 发送链接 <wxid or roomid> <链接> <缩略图绝对路径，为空时使用 ThumbURL>
+func (c *Client) SendLink(receiver string, link LinkMsg, thumb string) error {
+	return c.SendLinkCtx(context.Background(), receiver, link, thumb)
+}
+
+// SendLinkCtx 同 SendLink，支持 ctx 取消与超时
+func (c *Client) SendLinkCtx(ctx context.Context, receiver string, link LinkMsg, thumb string) error {
+	content, err := link.xml()
+	if err != nil {
+		return err
+	}
+	return c.sendXml(ctx, receiver, content, thumb, MsgType(appMsgTypeLink))
+}
+
+// SendLinkBytes 发送链接 <wxid or roomid> <链接> <缩略图字节>，缩略图写入临时文件后发送
+func (c *Client) SendLinkBytes(receiver string, link LinkMsg, thumb []byte) error {
+	return c.SendLinkBytesCtx(context.Background(), receiver, link, thumb)
+}
+
+// SendLinkBytesCtx 同 SendLinkBytes，支持 ctx 取消与超时
+func (c *Client) SendLinkBytesCtx(ctx context.Context, receiver string, link LinkMsg, thumb []byte) error {
+	if len(thumb) == 0 {
+		return c.SendLinkCtx(ctx, receiver, link, "")
+	}
+	ext := ".jpg"
+	if fileType, err := imgutil.DetectFileType(thumb); err == nil {
+		ext = imgutil.GetEtxByFileType(fileType)
+	}
+	tmpFile, err := imgutil.CreateTempFile(ext)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if removeErr := imgutil.RemoveTempFile(tmpFile.Name()); removeErr != nil {
+			c.logger.Error(removeErr, "imgutil.RemoveTempFile error in SendLinkBytes defer")
+		}
+	}()
+	_, err = tmpFile.Write(thumb)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("write link thumb: %w", err)
+	}
+	return c.SendLinkCtx(ctx, receiver, link, tmpFile.Name())
+}
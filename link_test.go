@@ -0,0 +1,76 @@
+package wcf_rpc_sdk
+
+import (
+	"github.com/Clov614/wcf-rpc-sdk/internal/wcf"
+	"os"
+	"testing"
+)
+
+const linkContent = `<?xml version="1.0"?>
+<msg>
+	<appmsg appid="" sdkver="0">
+		<title>一篇文章 &amp; 标题</title>
+		<des>文章摘要</des>
+		<type>5</type>
+		<url>https://mp.weixin.qq.com/s/abc?a=1&amp;b=2</url>
+		<thumburl>https://mmbiz.qpic.cn/thumb.jpg</thumburl>
+		<sourceusername>gh_123456</sourceusername>
+		<sourcedisplayname>某公众号</sourcedisplayname>
+	</appmsg>
+	<fromusername>wxid_friend</fromusername>
+	<appinfo><version>1</version><appname></appname></appinfo>
+</msg>`
+
+func TestParseLinkMsg(t *testing.T) {
+	got, err := parseLinkMsg(linkContent)
+	if err != nil {
+		t.Fatalf("parseLinkMsg() error = %v", err)
+	}
+	want := LinkMsg{Title: "一篇文章 & 标题", Desc: "文章摘要", URL: "https://mp.weixin.qq.com/s/abc?a=1&b=2", ThumbURL: "https://mmbiz.qpic.cn/thumb.jpg", SourceName: "某公众号", SourceUsername: "gh_123456"}
+	if *got != want {
+		t.Errorf("parseLinkMsg() = %+v, want %+v", *got, want)
+	}
+	if got, err = parseLinkMsg(`<msg><appmsg><title>a.txt</title><type>6</type></appmsg></msg>`); got != nil || err != nil {
+		t.Errorf("parseLinkMsg(file) = %+v, %v, want nil", got, err)
+	}
+}
+
+func TestClient_Link(t *testing.T) {
+	cli, srv := newOfflineClient(t)
+	if err := cli.Run(false); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	_ = srv.Push(&wcf.WxMsg{Id: 1, Type: uint32(MsgTypeXML), Sender: "wxid_friend", Content: linkContent})
+	msg := recvMsg(t, cli)
+	if msg.Type != MsgTypeXMLLink || msg.Link == nil || msg.Content != msg.Link.Title {
+		t.Fatalf("Type = %v, Link = %+v", msg.Type, msg.Link)
+	}
+
+	if err := cli.SendLink("wxid_friend", *msg.Link, "C:/thumb.jpg"); err != nil {
+		t.Fatalf("SendLink() error = %v", err)
+	}
+	reqs := srv.RequestsOf(wcf.Functions_FUNC_SEND_XML)
+	if len(reqs) != 1 || reqs[0].GetXml().GetType() != appMsgTypeLink || reqs[0].GetXml().GetPath() != "C:/thumb.jpg" {
+		t.Fatalf("send xml requests = %v", reqs)
+	}
+	sent, err := parseLinkMsg(reqs[0].GetXml().GetContent())
+	if err != nil || sent == nil || *sent != *msg.Link {
+		t.Errorf("sent link = %+v, %v, want %+v", sent, err, *msg.Link)
+	}
+
+	png := []byte{0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A, 0x00}
+	if err = cli.SendLinkBytes("wxid_friend", *msg.Link, png); err != nil {
+		t.Fatalf("SendLinkBytes() error = %v", err)
+	}
+	reqs = srv.RequestsOf(wcf.Functions_FUNC_SEND_XML)
+	if len(reqs) != 2 || reqs[1].GetXml().GetType() != appMsgTypeLink {
+		t.Fatalf("send xml requests = %v", reqs)
+	}
+	path := reqs[1].GetXml().GetPath()
+	if path == "" || path[len(path)-4:] != ".png" {
+		t.Errorf("thumb path = %q, want temp .png file", path)
+	}
+	if _, err = os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("temp thumb %q not removed: %v", path, err)
+	}
+}
@@ -0,0 +1,100 @@
+// Package wcf_rpc_sdk
+// @Author Clover
+// @Data 2026/10/17 上午2:10:00
+// @Desc 位置消息解析与发送
+package wcf_rpc_sdk
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+)
+
+// Location 位置消息
+type Location struct {
+	Latitude  float64 `json:"latitude"`           // 纬度，对应 x
+	Longitude float64 `json:"longitude"`          // 经度，对应 y
+	Scale     int     `json:"scale,omitempty"`    // 地图缩放级别
+	Label     string  `json:"label,omitempty"`    // 详细地址
+	PoiName   string  `json:"poi_name,omitempty"` // 地点名称
+	PoiId     string  `json:"poi_id,omitempty"`   // 地点 id，如 qqmap_xxx
+}
+
+// locationXml 位置消息的 XML，收发共用
+type locationXml struct {
+	XMLName  xml.Name `xml:"msg"`
+	Location struct {
+		X       string `xml:"x,attr"`
+		Y       string `xml:"y,attr"`
+		Scale   string `xml:"scale,attr"`
+		Label   string `xml:"label,attr"`
+		MapType string `xml:"maptype,attr"`
+		PoiName string `xml:"poiname,attr"`
+		PoiId   string `xml:"poiid,attr"`
+	} `xml:"location"`
+}
+
+// parseLocation 解析位置消息
+func parseLocation(content string) (*Location, error) {
+	var lx locationXml
+	if err := xml.Unmarshal([]byte(content), &lx); err != nil {
+		return nil, fmt.Errorf("xml.Unmarshal location: %w", err)
+	}
+	l := lx.Location
+	lat, err := strconv.ParseFloat(l.X, 64)
+	if err != nil {
+		return nil, fmt.Errorf("parse location x %q: %w", l.X, err)
+	}
+	lng, err := strconv.ParseFloat(l.Y, 64)
+	if err != nil {
+		return nil, fmt.Errorf("parse location y %q: %w", l.Y, err)
+	}
+	scale, _ := strconv.Atoi(l.Scale)
+	return &Location{Latitude: lat, Longitude: lng, Scale: scale, Label: l.Label, PoiName: l.PoiName, PoiId: l.PoiId}, nil
+}
+
+// xml 生成发送位置所用的 XML
+func (l Location) xml() (string, error) {
+	var lx locationXml
+	lx.Location.X = strconv.FormatFloat(l.Latitude, 'f', -1, 64)
+	lx.Location.Y = strconv.FormatFloat(l.Longitude, 'f', -1, 64)
+	scale := l.Scale
+	if scale == 0 {
+		scale = 15
+	}
+	lx.Location.Scale = strconv.Itoa(scale)
+	lx.Location.Label = l.Label
+	lx.Location.MapType = "roadmap"
+	lx.Location.PoiName = l.PoiName
+	lx.Location.PoiId = l.PoiId
+	data, err := xml.Marshal(lx)
+	if err != nil {
+		return "", fmt.Errorf("xml.Marshal location: %w", err)
+	}
+	return string(data), nil
+}
+
+// sendXml 通过 FUNC_SEND_XML 发送 <接收人> <xml 内容> <封面图片路径，可为空> <xml 类型>
+func (c *Client) sendXml(ctx context.Context, receiver, content, path string, typ MsgType) error {
+	res, err := c.wxClient.SendXmlCtx(ctx, path, content, receiver, int32(typ))
+	if err != nil {
+		c.logger.Debug("wxClient.SendXml", map[string]interface{}{"res": res, "receiver": receiver, "type": typ, "xml": content})
+		return fmt.Errorf("wxClient.SendXml: %w", err)
+	}
+	return nil
+}
+
+// SendLocation 发送位置 <wxid or roomid> <位置>，Scale 为 0 时使用 15
+func (c *Client) SendLocation(receiver string, loc Location) error {
+	return c.SendLocationCtx(context.Background(), receiver, loc)
+}
+
+// SendLocationCtx 同 SendLocation，支持 ctx 取消与超时
+func (c *Client) SendLocationCtx(ctx context.Context, receiver string, loc Location) error {
+	content, err := loc.xml()
+	if err != nil {
+		return err
+	}
+	return c.sendXml(ctx, receiver, content, "", MsgTypeLocation)
+}
@@ -0,0 +1,49 @@
+package wcf_rpc_sdk
+
+import (
+	"github.com/Clov614/wcf-rpc-sdk/internal/wcf"
+	"testing"
+)
+
+const locationContent = `<?xml version="1.0"?>
+<msg>
+	<location x="30.274085" y="120.155070" scale="16" label="浙江省杭州市西湖区龙井路1号" maptype="roadmap" poiname="西湖&amp;断桥" poiid="qqmap_3427718923" buildingId="" floorName="" poiCategoryTips="" poiBusinessHour="" poiPhone="" poiPriceTips="" isFromPoiList="true" adcode="" cityname="杭州市" />
+</msg>`
+
+func TestParseLocation(t *testing.T) {
+	got, err := parseLocation(locationContent)
+	if err != nil {
+		t.Fatalf("parseLocation() error = %v", err)
+	}
+	want := Location{Latitude: 30.274085, Longitude: 120.15507, Scale: 16, Label: "浙江省杭州市西湖区龙井路1号", PoiName: "西湖&断桥", PoiId: "qqmap_3427718923"}
+	if *got != want {
+		t.Errorf("parseLocation() = %+v, want %+v", *got, want)
+	}
+	if _, err = parseLocation(`<msg><location x="" y="1"/></msg>`); err == nil {
+		t.Errorf("parseLocation(empty x) error = nil")
+	}
+}
+
+func TestClient_Location(t *testing.T) {
+	cli, srv := newOfflineClient(t)
+	if err := cli.Run(false); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	_ = srv.Push(&wcf.WxMsg{Id: 1, Type: uint32(MsgTypeLocation), Sender: "wxid_friend", Content: locationContent})
+	msg := recvMsg(t, cli)
+	if msg.Location == nil || msg.Location.PoiName != "西湖&断桥" {
+		t.Fatalf("Location = %+v", msg.Location)
+	}
+
+	if err := cli.SendLocation("wxid_friend", *msg.Location); err != nil {
+		t.Fatalf("SendLocation() error = %v", err)
+	}
+	reqs := srv.RequestsOf(wcf.Functions_FUNC_SEND_XML)
+	if len(reqs) != 1 || reqs[0].GetXml().GetType() != int32(MsgTypeLocation) || reqs[0].GetXml().GetReceiver() != "wxid_friend" {
+		t.Fatalf("send xml requests = %v", reqs)
+	}
+	sent, err := parseLocation(reqs[0].GetXml().GetContent())
+	if err != nil || *sent != *msg.Location {
+		t.Errorf("sent location = %+v, %v, want %+v", sent, err, *msg.Location)
+	}
+}
@@ -0,0 +1,136 @@
+// Package wcf_rpc_sdk
+// @Author Clover
+// @Data 2026/10/16 下午8:30:00
+// @Desc 日志接口及适配器
+package wcf_rpc_sdk
+
+import (
+	"context"
+	"github.com/Clov614/logging"
+	"github.com/Clov614/wcf-rpc-sdk/internal/utils/logutil"
+	"github.com/rs/zerolog"
+	"log/slog"
+)
+
+// Logger SDK 使用的日志接口，可通过 WithLogger 替换；Debug 日志是否输出由 Run(debug) 决定，仅作用于当前客户端
+type Logger = logutil.Logger
+
+var sdkField = map[string]interface{}{"sdk": "wcf-rpc-sdk"}
+
+// defaultLogger 默认使用 github.com/Clov614/logging 输出，每条日志附加 sdk 字段，不修改其全局配置
+type defaultLogger struct{}
+
+func withSDKField(fields []map[string]interface{}) []map[string]interface{} {
+	return append([]map[string]interface{}{sdkField}, fields...)
+}
+
+func (defaultLogger) Debug(msg string, fields ...map[string]interface{}) {
+	logging.Debug(msg, withSDKField(fields)...)
+}
+
+func (defaultLogger) Info(msg string, fields ...map[string]interface{}) {
+	logging.Info(msg, withSDKField(fields)...)
+}
+
+func (defaultLogger) Warn(err error, msg string, fields ...map[string]interface{}) {
+	if err == nil {
+		logging.Warn(msg, withSDKField(fields)...)
+		return
+	}
+	logging.WarnWithErr(err, msg, withSDKField(fields)...)
+}
+
+func (defaultLogger) Error(err error, msg string, fields ...map[string]interface{}) {
+	if err == nil {
+		logging.Error(msg, withSDKField(fields)...)
+		return
+	}
+	logging.ErrorWithErr(err, msg, withSDKField(fields)...)
+}
+
+// NewNopLogger 丢弃所有日志
+func NewNopLogger() Logger {
+	return logutil.Nop{}
+}
+
+// NewSlogLogger 适配 log/slog，logger 为 nil 时使用 slog.Default()
+func NewSlogLogger(logger *slog.Logger) Logger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return slogLogger{l: logger}
+}
+
+type slogLogger struct {
+	l *slog.Logger
+}
+
+func (s slogLogger) log(level slog.Level, err error, msg string, fields []map[string]interface{}) {
+	ctx := context.Background()
+	if !s.l.Enabled(ctx, level) {
+		return
+	}
+	merged := logutil.MergeFields(fields...)
+	attrs := make([]slog.Attr, 0, len(merged)+1)
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	for k, v := range merged {
+		attrs = append(attrs, slog.Any(k, v))
+	}
+	s.l.LogAttrs(ctx, level, msg, attrs...)
+}
+
+func (s slogLogger) Debug(msg string, fields ...map[string]interface{}) {
+	s.log(slog.LevelDebug, nil, msg, fields)
+}
+
+func (s slogLogger) Info(msg string, fields ...map[string]interface{}) {
+	s.log(slog.LevelInfo, nil, msg, fields)
+}
+
+func (s slogLogger) Warn(err error, msg string, fields ...map[string]interface{}) {
+	s.log(slog.LevelWarn, err, msg, fields)
+}
+
+func (s slogLogger) Error(err error, msg string, fields ...map[string]interface{}) {
+	s.log(slog.LevelError, err, msg, fields)
+}
+
+// NewZerologLogger 适配 zerolog，日志级别以传入 logger 自身的级别为准
+func NewZerologLogger(logger zerolog.Logger) Logger {
+	return zerologLogger{l: logger}
+}
+
+type zerologLogger struct {
+	l zerolog.Logger
+}
+
+func (z zerologLogger) log(event *zerolog.Event, err error, msg string, fields []map[string]interface{}) {
+	if event == nil { // 级别被过滤
+		return
+	}
+	if err != nil {
+		event = event.Err(err)
+	}
+	for _, field := range fields {
+		event = event.Fields(field)
+	}
+	event.Msg(msg)
+}
+
+func (z zerologLogger) Debug(msg string, fields ...map[string]interface{}) {
+	z.log(z.l.Debug(), nil, msg, fields)
+}
+
+func (z zerologLogger) Info(msg string, fields ...map[string]interface{}) {
+	z.log(z.l.Info(), nil, msg, fields)
+}
+
+func (z zerologLogger) Warn(err error, msg string, fields ...map[string]interface{}) {
+	z.log(z.l.Warn(), err, msg, fields)
+}
+
+func (z zerologLogger) Error(err error, msg string, fields ...map[string]interface{}) {
+	z.log(z.l.Error(), err, msg, fields)
+}
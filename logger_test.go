@@ -0,0 +1,119 @@
+package wcf_rpc_sdk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/Clov614/wcf-rpc-sdk/internal/wcftest"
+	"github.com/rs/zerolog"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordLogger 记录日志消息，用于断言
+type recordLogger struct {
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (r *recordLogger) add(level, msg string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.msgs = append(r.msgs, level+":"+msg)
+}
+
+func (r *recordLogger) has(entry string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, msg := range r.msgs {
+		if msg == entry {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *recordLogger) Debug(msg string, _ ...map[string]interface{}) { r.add("debug", msg) }
+func (r *recordLogger) Info(msg string, _ ...map[string]interface{})  { r.add("info", msg) }
+func (r *recordLogger) Warn(_ error, msg string, _ ...map[string]interface{}) {
+	r.add("warn", msg)
+}
+func (r *recordLogger) Error(_ error, msg string, _ ...map[string]interface{}) {
+	r.add("error", msg)
+}
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})))
+	logger.Debug("hidden")
+	logger.Warn(errors.New("boom"), "warned", map[string]interface{}{"k": 1})
+	out := buf.String()
+	if strings.Contains(out, "hidden") {
+		t.Errorf("debug log should be filtered by handler level: %s", out)
+	}
+	for _, want := range []string{`"level":"WARN"`, `"msg":"warned"`, `"error":"boom"`, `"k":1`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("slog output %s missing %s", out, want)
+		}
+	}
+}
+
+func TestZerologLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewZerologLogger(zerolog.New(&buf).Level(zerolog.InfoLevel))
+	logger.Debug("hidden")
+	logger.Error(errors.New("boom"), "failed", map[string]interface{}{"k": "v"})
+	out := buf.String()
+	if strings.Contains(out, "hidden") {
+		t.Errorf("debug log should be filtered by logger level: %s", out)
+	}
+	for _, want := range []string{`"level":"error"`, `"message":"failed"`, `"error":"boom"`, `"k":"v"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("zerolog output %s missing %s", out, want)
+		}
+	}
+}
+
+// TestRun_DebugScoped Run(debug) 只影响当前客户端，不修改全局日志级别
+func TestRun_DebugScoped(t *testing.T) {
+	globalLevel := zerolog.GlobalLevel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	newClient := func(logger Logger) *Client {
+		srv, err := wcftest.NewServer()
+		if err != nil {
+			t.Fatalf("wcftest.NewServer() error = %v", err)
+		}
+		t.Cleanup(func() { _ = srv.Close() })
+		cli, err := New(ctx, WithAddr(srv.Addr()), WithLogger(logger))
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		t.Cleanup(cli.Close)
+		return cli
+	}
+	logA, logB := &recordLogger{}, &recordLogger{}
+	cliA, cliB := newClient(logA), newClient(logB)
+	if err := cliA.Run(true); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if err := cliB.Run(false); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got := zerolog.GlobalLevel(); got != globalLevel {
+		t.Errorf("zerolog global level changed: %v -> %v", globalLevel, got)
+	}
+	if !logA.has("debug:Debug mode enabled") {
+		t.Errorf("client A should log debug messages")
+	}
+	cliB.logger.Debug("should be dropped")
+	if logB.has("debug:should be dropped") || logB.has("debug:Debug mode enabled") {
+		t.Errorf("client B should not log debug messages")
+	}
+	cliB.logger.Info("kept")
+	if !logB.has("info:kept") {
+		t.Errorf("client B should still log info messages")
+	}
+}
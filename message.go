@@ -8,7 +8,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"github.com/Clov614/logging"
 	"github.com/Clov614/wcf-rpc-sdk/internal/utils/imgutil"
 	"path/filepath"
 	"regexp"
@@ -16,7 +15,8 @@ import (
 )
 
 var (
-	ErrBufferFull = errors.New("the message buffer is full")
+	ErrBufferFull   = errors.New("the message buffer is full")
+	ErrNotFriendReq = errors.New("the message is not a new friend request")
 )
 
 type IMeta interface {
@@ -24,7 +24,8 @@ type IMeta interface {
 	ReplyImage(src string) error
 	ReplyFile(src string) error
 	IsSendByFriend() bool
-	AcceptNewFriend(req NewFriendReq) bool
+	AcceptNewFriend(req NewFriendReq) error
+	AcceptTransfer(t TransferMsg) error
 }
 
 // 用于回调
@@ -51,10 +52,19 @@ func (m *meta) ReplyFile(src string) error {
 }
 
 // AcceptNewFriend 通过好友请求
-func (m *meta) AcceptNewFriend(req NewFriendReq) bool {
+func (m *meta) AcceptNewFriend(req NewFriendReq) error {
 	return m.cli.AcceptNewFriend(req)
 }
 
+// AcceptTransfer 收取转账
+func (m *meta) AcceptTransfer(t TransferMsg) error {
+	payer := t.Payer
+	if payer == "" {
+		payer = m.rawMsg.WxId
+	}
+	return m.cli.ReceiveTransfer(payer, t.TransferId, t.TransactionId)
+}
+
 // IsSendByFriend 是否好友发送的消息
 func (m *meta) IsSendByFriend() bool {
 	if m.rawMsg.IsSelf {
@@ -83,6 +93,16 @@ type Message struct {
 	Quote        *QuoteMsg     `json:"quote,omitempty"`          // 引用消息
 	Forward      *ForwardMsg   `json:"forward,omitempty"`        // 转发消息
 	NewFriendReq *NewFriendReq `json:"new_friend_req,omitempty"` // 新好友请求
+	Transfer     *TransferMsg  `json:"transfer,omitempty"`       // 转账
+	Location     *Location     `json:"location,omitempty"`       // 位置
+	Card         *BusinessCard `json:"card,omitempty"`           // 名片
+	Link         *LinkMsg      `json:"link,omitempty"`           // 链接
+
+	MiniProgram *MiniProgramMsg `json:"mini_program,omitempty"` // 小程序
+	GroupEvent  GroupEvent      `json:"group_event,omitempty"`  // 群系统消息事件
+	Pat         *PatEvent       `json:"pat,omitempty"`          // 拍一拍
+	RedPacket   *RedPacketMsg   `json:"red_packet,omitempty"`   // 红包
+	Video       *VideoMsg       `json:"video,omitempty"`        // 视频
 
 	//UserInfo *UserInfo `json:"user_info,omitempty"` todo
 	//Contacts *Contacts `json:"contact,omitempty"`
@@ -101,18 +121,23 @@ type FileInfo struct {
 	FileName                   string `json:"file_name,omitempty"`                      // File name including extension
 	FileExt                    string `json:"file_ext,omitempty"`                       // File extension
 	IsImg                      bool   `json:"is_img,omitempty"`                         // Indicates if the file is an image
-	Data                       []byte `json:"-"	`                                       // 图片数据
+	Data                       []byte `json:"-"`                                        // 图片数据
+	logger                     Logger
 }
 
 // DecryptImg 解析图片信息
 func (fi *FileInfo) DecryptImg() (err error) {
-	fi.Data, err = imgutil.DecodeDatFileToBytes(fi.FilePath)
+	logger := fi.logger
+	if logger == nil {
+		logger = defaultLogger{}
+	}
+	fi.Data, err = imgutil.DecodeDatFileToBytes(fi.FilePath, logger)
 	if err != nil {
 		return fmt.Errorf("decrypt img error: %w", err)
 	}
 	fileType, err := imgutil.DetectFileType(fi.Data)
 	if err != nil {
-		logging.WarnWithErr(err, "detect file type")
+		logger.Warn(err, "detect file type")
 	}
 	fi.FileExt = string(fileType)
 	return nil
@@ -156,21 +181,23 @@ func (m *Message) IsSendByFriend() bool {
 }
 
 // AcceptNewFriend 通过好友请求
-func (m *Message) AcceptNewFriend() bool {
+func (m *Message) AcceptNewFriend() error {
 	if m.NewFriendReq == nil {
-		return false
+		return ErrNotFriendReq
 	}
 	return m.meta.AcceptNewFriend(*m.NewFriendReq)
 }
 
 type MessageBuffer struct {
-	msgCH chan *Message // 原始消息输入通道
+	msgCH  chan *Message // 原始消息输入通道
+	logger Logger
 }
 
 // NewMessageBuffer 创建消息缓冲区 <缓冲大小>
 func NewMessageBuffer(bufferSize int) *MessageBuffer {
 	mb := &MessageBuffer{
-		msgCH: make(chan *Message, bufferSize),
+		msgCH:  make(chan *Message, bufferSize),
+		logger: defaultLogger{},
 	}
 	return mb
 }
@@ -183,10 +210,10 @@ func (mb *MessageBuffer) Put(ctx context.Context, msg *Message) error {
 		case <-ctx.Done():
 			return ctx.Err()
 		case mb.msgCH <- msg:
-			logging.Debug("put message to buffer", map[string]interface{}{"msg": msg})
+			mb.logger.Debug("put message to buffer", map[string]interface{}{"msg": msg})
 			return nil
 		default:
-			logging.Warn("message buffer is full, retrying", map[string]interface{}{fmt.Sprintf("%d", i+1): retries})
+			mb.logger.Warn(nil, "message buffer is full, retrying", map[string]interface{}{fmt.Sprintf("%d", i+1): retries})
 		}
 
 		//// Optional: add a small delay before retrying to prevent busy-waiting
@@ -201,7 +228,7 @@ func (mb *MessageBuffer) Get(ctx context.Context) (*Message, error) {
 	case <-ctx.Done():
 		return nil, ctx.Err()
 	case msg := <-mb.msgCH:
-		logging.Debug("retrieved message pair from buffer", map[string]interface{}{"msg": msg})
+		mb.logger.Debug("retrieved message pair from buffer", map[string]interface{}{"msg": msg})
 		return msg, nil
 	}
 }
@@ -248,8 +275,12 @@ type RoomData struct {
 
 // AnalyseMemberAt 检查并生成成员@情况
 func (rd *RoomData) AnalyseMemberAt(selfWxid string, content string) {
+	rd.analyseMemberAt(selfWxid, content, defaultLogger{})
+}
+
+func (rd *RoomData) analyseMemberAt(selfWxid string, content string, logger Logger) {
 	if selfWxid == "" {
-		logging.Error("analyse member at wxid error", map[string]interface{}{"wxid": selfWxid})
+		logger.Error(nil, "analyse member at wxid error", map[string]interface{}{"wxid": selfWxid})
 		return
 	}
 	if rd.Members == nil || len(rd.Members) == 0 {
@@ -266,7 +297,7 @@ func (rd *RoomData) AnalyseMemberAt(selfWxid string, content string) {
 			// 检查 msg.RoomData 是否为 nil
 			infos, err := rd.GetMembersByNickName(match[1])
 			if err != nil || infos[0] == nil {
-				logging.WarnWithErr(err, "RoomData.GetMembersByNickName fail")
+				logger.Warn(err, "RoomData.GetMembersByNickName fail")
 				continue
 			}
 			rd.AtedMSequence[i] = infos[0]
@@ -362,6 +393,14 @@ type ContactInfo struct {
 	SmallHeadURL string `json:"small_head_url,omitempty"`
 	// 大头像
 	BigHeadURL string `json:"big_head_url,omitempty"`
+	// 国家（国家、省、城市、性别仅在通过 FUNC_GET_CONTACT_INFO 查询时有值）
+	Country string `json:"country,omitempty"`
+	// 省/州
+	Province string `json:"province,omitempty"`
+	// 城市
+	City string `json:"city,omitempty"`
+	// 性别 1 男 2 女
+	Gender int32 `json:"gender,omitempty"`
 }
 
 type GH User // todo 公众号
@@ -384,6 +423,8 @@ const (
 	MsgTypeXMLImage          MsgType = 4903    // XML 中的图片消息
 	MsgTypeXMLFile           MsgType = 4906    // XML 中的文件消息
 	MsgTypeXMLLink           MsgType = 4916    // XML 中的链接消息
+	MsgTypeXMLTransfer       MsgType = 4920    // XML 中的转账消息 (appmsg type 2000)
+	MsgTypeXMLMiniProgram    MsgType = 4933    // XML 中的小程序消息 (appmsg type 33/36)
 	MsgTypeVoip              MsgType = 50      // VOIPMSG
 	MsgTypeWechatInit        MsgType = 51      // 微信初始化
 	MsgTypeVoipNotify        MsgType = 52      // VOIPNOTIFY
@@ -448,6 +489,8 @@ var MsgTypeNames = map[MsgType]string{
 	MsgTypeMusicLink:         "音乐链接",
 	MsgTypeFile:              "文件",
 	MsgTypeXMLForward:        "转发消息", // 新增
+	MsgTypeXMLTransfer:       "转账",
+	MsgTypeXMLMiniProgram:    "小程序",
 }
 
 // QuoteMsg 引用消息
@@ -0,0 +1,167 @@
+// Package wcf_rpc_sdk
+// @Author Clover
+// @Data 2026/10/17 上午3:20:00
+// @Desc 小程序卡片消息解析与发送
+package wcf_rpc_sdk
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+)
+
+const (
+	appMsgTypeMiniProgram     = 33 // appmsg 中小程序分享的 type，发送时作为 xml 类型 0x21
+	appMsgTypeMiniProgramCard = 36 // 带自定义链接的小程序卡片
+)
+
+// MiniProgramThumb 小程序卡片封面在 CDN 上的信息，转发收到的卡片时可直接复用
+type MiniProgramThumb struct {
+	URL    string `json:"url,omitempty"` // cdnthumburl
+	MD5    string `json:"md5,omitempty"`
+	AesKey string `json:"aes_key,omitempty"`
+	Length int    `json:"length,omitempty"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+}
+
+// MiniProgram 小程序卡片，可直接用于 SendMiniProgram
+type MiniProgram struct {
+	AppId       string           `json:"app_id"`
+	Username    string           `json:"username"`               // 小程序原始 id，gh_ 开头，以 @app 结尾
+	PagePath    string           `json:"page_path,omitempty"`    // 打开的页面路径
+	Title       string           `json:"title"`                  // 卡片标题
+	DisplayName string           `json:"display_name,omitempty"` // 小程序名称
+	IconURL     string           `json:"icon_url,omitempty"`     // 小程序图标
+	URL         string           `json:"url,omitempty"`          // 不支持小程序时打开的网页
+	Version     int              `json:"version,omitempty"`      // weappinfo 中的版本号
+	Thumb       MiniProgramThumb `json:"thumb"`
+	ThumbPath   string           `json:"-"` // 发送时使用的本地封面图片，为空时使用 Thumb 中的 CDN 信息
+}
+
+// MiniProgramMsg 小程序消息
+type MiniProgramMsg struct {
+	MiniProgram
+	AppMsgType int `json:"app_msg_type"` // 33 或 36
+}
+
+var errMiniProgramAppId = errors.New("mini program without appid")
+
+// miniProgramXml 小程序 appmsg，解析与发送共用
+type miniProgramXml struct {
+	XMLName xml.Name `xml:"msg"`
+	AppMsg  struct {
+		AppId             string `xml:"appid,attr"`
+		SdkVer            string `xml:"sdkver,attr"`
+		Title             string `xml:"title"`
+		Des               string `xml:"des"`
+		Type              int    `xml:"type"`
+		URL               string `xml:"url"`
+		SourceUsername    string `xml:"sourceusername"`
+		SourceDisplayName string `xml:"sourcedisplayname"`
+		AppAttach         struct {
+			CdnThumbURL    string `xml:"cdnthumburl,omitempty"`
+			CdnThumbMD5    string `xml:"cdnthumbmd5,omitempty"`
+			CdnThumbLength int    `xml:"cdnthumblength,omitempty"`
+			CdnThumbWidth  int    `xml:"cdnthumbwidth,omitempty"`
+			CdnThumbHeight int    `xml:"cdnthumbheight,omitempty"`
+			CdnThumbAesKey string `xml:"cdnthumbaeskey,omitempty"`
+			AesKey         string `xml:"aeskey,omitempty"`
+		} `xml:"appattach"`
+		WeAppInfo struct {
+			PagePath     string `xml:"pagepath"`
+			Username     string `xml:"username"`
+			AppId        string `xml:"appid"`
+			Version      int    `xml:"version"`
+			Type         int    `xml:"type"`
+			WeAppIconURL string `xml:"weappiconurl"`
+		} `xml:"weappinfo"`
+	} `xml:"appmsg"`
+}
+
+// parseMiniProgramMsg 解析 appmsg type 为 33 或 36 的小程序消息，其他消息返回 nil
+func parseMiniProgramMsg(content string) (*MiniProgramMsg, error) {
+	var mx miniProgramXml
+	if err := xml.Unmarshal([]byte(content), &mx); err != nil {
+		return nil, fmt.Errorf("xml.Unmarshal mini program: %w", err)
+	}
+	a := mx.AppMsg
+	if a.Type != appMsgTypeMiniProgram && a.Type != appMsgTypeMiniProgramCard {
+		return nil, nil
+	}
+	mp := &MiniProgramMsg{
+		MiniProgram: MiniProgram{
+			AppId:       a.WeAppInfo.AppId,
+			Username:    a.WeAppInfo.Username,
+			PagePath:    a.WeAppInfo.PagePath,
+			Title:       a.Title,
+			DisplayName: a.SourceDisplayName,
+			IconURL:     a.WeAppInfo.WeAppIconURL,
+			URL:         a.URL,
+			Version:     a.WeAppInfo.Version,
+			Thumb: MiniProgramThumb{
+				URL:    a.AppAttach.CdnThumbURL,
+				MD5:    a.AppAttach.CdnThumbMD5,
+				AesKey: a.AppAttach.CdnThumbAesKey,
+				Length: a.AppAttach.CdnThumbLength,
+				Width:  a.AppAttach.CdnThumbWidth,
+				Height: a.AppAttach.CdnThumbHeight,
+			},
+		},
+		AppMsgType: a.Type,
+	}
+	if mp.Username == "" {
+		mp.Username = a.SourceUsername
+	}
+	if mp.Thumb.AesKey == "" {
+		mp.Thumb.AesKey = a.AppAttach.AesKey
+	}
+	return mp, nil
+}
+
+func (mp MiniProgram) xml() (string, error) {
+	if mp.AppId == "" {
+		return "", errMiniProgramAppId
+	}
+	var mx miniProgramXml
+	a := &mx.AppMsg
+	a.SdkVer = "0"
+	a.Title = mp.Title
+	a.Type = appMsgTypeMiniProgram
+	a.URL = mp.URL
+	a.SourceUsername = mp.Username
+	a.SourceDisplayName = mp.DisplayName
+	a.AppAttach.CdnThumbURL = mp.Thumb.URL
+	a.AppAttach.CdnThumbMD5 = mp.Thumb.MD5
+	a.AppAttach.CdnThumbLength = mp.Thumb.Length
+	a.AppAttach.CdnThumbWidth = mp.Thumb.Width
+	a.AppAttach.CdnThumbHeight = mp.Thumb.Height
+	a.AppAttach.CdnThumbAesKey = mp.Thumb.AesKey
+	a.AppAttach.AesKey = mp.Thumb.AesKey
+	a.WeAppInfo.PagePath = mp.PagePath
+	a.WeAppInfo.Username = mp.Username
+	a.WeAppInfo.AppId = mp.AppId
+	a.WeAppInfo.Version = mp.Version
+	a.WeAppInfo.Type = 2
+	a.WeAppInfo.WeAppIconURL = mp.IconURL
+	data, err := xml.Marshal(mx)
+	if err != nil {
+		return "", fmt.Errorf("xml.Marshal mini program: %w", err)
+	}
+	return xml.Header + string(data), nil
+}
+
+// SendMiniProgram 发送小程序卡片 <wxid or roomid> <小程序>，转发收到的卡片时可传入 msg.MiniProgram.MiniProgram
+func (c *Client) SendMiniProgram(receiver string, mp MiniProgram) error {
+	return c.SendMiniProgramCtx(context.Background(), receiver, mp)
+}
+
+// SendMiniProgramCtx 同 SendMiniProgram，支持 ctx 取消与超时
+func (c *Client) SendMiniProgramCtx(ctx context.Context, receiver string, mp MiniProgram) error {
+	content, err := mp.xml()
+	if err != nil {
+		return err
+	}
+	return c.sendXml(ctx, receiver, content, mp.ThumbPath, MsgType(appMsgTypeMiniProgram))
+}
@@ -0,0 +1,92 @@
+package wcf_rpc_sdk
+
+import (
+	"github.com/Clov614/wcf-rpc-sdk/internal/wcf"
+	"testing"
+)
+
+const miniProgramContent = `<?xml version="1.0"?>
+<msg>
+	<appmsg appid="" sdkver="0">
+		<title>点一杯咖啡</title>
+		<des />
+		<type>33</type>
+		<url>https://mp.weixin.qq.com/mp/waerrpage?appid=wx1234567890&amp;type=upgrade</url>
+		<sourceusername>gh_abcdef@app</sourceusername>
+		<sourcedisplayname>咖啡小程序</sourcedisplayname>
+		<appattach>
+			<cdnthumburl>3057020100044b30490201</cdnthumburl>
+			<cdnthumbmd5>0123456789abcdef</cdnthumbmd5>
+			<cdnthumblength>65536</cdnthumblength>
+			<cdnthumbwidth>500</cdnthumbwidth>
+			<cdnthumbheight>400</cdnthumbheight>
+			<cdnthumbaeskey>aeskey123</cdnthumbaeskey>
+			<aeskey>aeskey123</aeskey>
+		</appattach>
+		<weappinfo>
+			<pagepath><![CDATA[pages/menu/index.html?shop=1&table=2]]></pagepath>
+			<username>gh_abcdef@app</username>
+			<appid>wx1234567890</appid>
+			<version>12</version>
+			<type>2</type>
+			<weappiconurl><![CDATA[http://mmbiz.qpic.cn/icon.png]]></weappiconurl>
+		</weappinfo>
+	</appmsg>
+	<fromusername>wxid_friend</fromusername>
+</msg>`
+
+func TestParseMiniProgramMsg(t *testing.T) {
+	got, err := parseMiniProgramMsg(miniProgramContent)
+	if err != nil {
+		t.Fatalf("parseMiniProgramMsg() error = %v", err)
+	}
+	want := MiniProgramMsg{
+		MiniProgram: MiniProgram{
+			AppId:       "wx1234567890",
+			Username:    "gh_abcdef@app",
+			PagePath:    "pages/menu/index.html?shop=1&table=2",
+			Title:       "点一杯咖啡",
+			DisplayName: "咖啡小程序",
+			IconURL:     "http://mmbiz.qpic.cn/icon.png",
+			URL:         "https://mp.weixin.qq.com/mp/waerrpage?appid=wx1234567890&type=upgrade",
+			Version:     12,
+			Thumb:       MiniProgramThumb{URL: "3057020100044b30490201", MD5: "0123456789abcdef", AesKey: "aeskey123", Length: 65536, Width: 500, Height: 400},
+		},
+		AppMsgType: 33,
+	}
+	if *got != want {
+		t.Errorf("parseMiniProgramMsg() = %+v, want %+v", *got, want)
+	}
+	if got, err = parseMiniProgramMsg(linkContent); got != nil || err != nil {
+		t.Errorf("parseMiniProgramMsg(link) = %+v, %v, want nil", got, err)
+	}
+}
+
+func TestClient_MiniProgram(t *testing.T) {
+	cli, srv := newOfflineClient(t)
+	if err := cli.Run(false); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	_ = srv.Push(&wcf.WxMsg{Id: 1, Type: uint32(MsgTypeXML), Sender: "wxid_friend", Content: miniProgramContent})
+	msg := recvMsg(t, cli)
+	if msg.Type != MsgTypeXMLMiniProgram || msg.MiniProgram == nil || msg.Content != "点一杯咖啡" {
+		t.Fatalf("Type = %v, MiniProgram = %+v", msg.Type, msg.MiniProgram)
+	}
+
+	mp := msg.MiniProgram.MiniProgram
+	mp.ThumbPath = "C:/cover.jpg"
+	if err := cli.SendMiniProgram("45959390469@chatroom", mp); err != nil {
+		t.Fatalf("SendMiniProgram() error = %v", err)
+	}
+	reqs := srv.RequestsOf(wcf.Functions_FUNC_SEND_XML)
+	if len(reqs) != 1 || reqs[0].GetXml().GetType() != 0x21 || reqs[0].GetXml().GetPath() != "C:/cover.jpg" {
+		t.Fatalf("send xml requests = %v", reqs)
+	}
+	sent, err := parseMiniProgramMsg(reqs[0].GetXml().GetContent())
+	if err != nil || sent == nil || sent.MiniProgram != msg.MiniProgram.MiniProgram {
+		t.Errorf("sent mini program = %+v, %v, want %+v", sent, err, msg.MiniProgram.MiniProgram)
+	}
+	if err = cli.SendMiniProgram("wxid_friend", MiniProgram{Title: "no appid"}); err == nil {
+		t.Errorf("SendMiniProgram(no appid) error = nil")
+	}
+}
@@ -0,0 +1,230 @@
+// Package wcf_rpc_sdk
+// @Author Clover
+// @Data 2026/10/17 上午12:40:00
+// @Desc 朋友圈动态解析与推送
+package wcf_rpc_sdk
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/Clov614/wcf-rpc-sdk/internal/wcf"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultMomentBufferSize = 64   // 朋友圈动态通道缓冲大小
+	momentSeenLimit         = 4096 // 去重时记住的动态 id 数量
+)
+
+var ErrInvalidMoment = errors.New("invalid moment xml")
+
+// MomentPost 朋友圈动态
+type MomentPost struct {
+	Id          uint64          `json:"id"`                 // 动态 id，可作为 RefreshMoments 的翻页起点
+	MessageId   uint64          `json:"message_id"`         // 推送该动态的消息 id
+	Author      string          `json:"author"`             // 发布者 wxid
+	Text        string          `json:"text,omitempty"`     // 正文
+	ContentType int             `json:"content_type"`       // 内容类型 1 图片 2 纯文字 3 链接 15 视频
+	Title       string          `json:"title,omitempty"`    // 链接标题
+	URL         string          `json:"url,omitempty"`      // 链接地址
+	Media       []MomentMedia   `json:"media,omitempty"`    // 图片、视频
+	Location    *MomentLocation `json:"location,omitempty"` // 定位，未设置时为 nil
+	CreateTime  time.Time       `json:"create_time"`        // 发布时间
+	ReceivedAt  time.Time       `json:"received_at"`        // 收到推送的时间
+	Xml         string          `json:"xml,omitempty"`      // 原始 TimelineObject
+}
+
+// MomentMedia 动态中的图片或视频
+type MomentMedia struct {
+	Id     string `json:"id"`
+	Type   int    `json:"type"` // 2 图片 6 视频
+	URL    string `json:"url"`
+	Thumb  string `json:"thumb,omitempty"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+}
+
+// MomentLocation 动态的定位信息
+type MomentLocation struct {
+	PoiName   string  `json:"poi_name,omitempty"`
+	Address   string  `json:"address,omitempty"`
+	City      string  `json:"city,omitempty"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// timelineObject TimelineObject 中用到的字段
+type timelineObject struct {
+	Id          string `xml:"id"`
+	Username    string `xml:"username"`
+	CreateTime  int64  `xml:"createTime"`
+	ContentDesc string `xml:"contentDesc"`
+	Location    struct {
+		PoiName    string `xml:"poiName,attr"`
+		PoiAddress string `xml:"poiAddress,attr"`
+		City       string `xml:"city,attr"`
+		Latitude   string `xml:"latitude,attr"`
+		Longitude  string `xml:"longitude,attr"`
+	} `xml:"location"`
+	ContentObject struct {
+		ContentStyle int    `xml:"contentStyle"`
+		Title        string `xml:"title"`
+		ContentUrl   string `xml:"contentUrl"`
+		Media        []struct {
+			Id    string `xml:"id"`
+			Type  int    `xml:"type"`
+			URL   string `xml:"url"`
+			Thumb string `xml:"thumb"`
+			Size  struct {
+				Width  string `xml:"width,attr"`
+				Height string `xml:"height,attr"`
+			} `xml:"size"`
+		} `xml:"mediaList>media"`
+	} `xml:"ContentObject"`
+}
+
+// ParseMoment 解析朋友圈 TimelineObject XML
+func ParseMoment(data string) (*MomentPost, error) {
+	start := strings.Index(data, "<TimelineObject")
+	if start < 0 {
+		return nil, fmt.Errorf("%w: missing TimelineObject", ErrInvalidMoment)
+	}
+	var obj timelineObject
+	if err := xml.NewDecoder(strings.NewReader(data[start:])).Decode(&obj); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidMoment, err)
+	}
+	post := &MomentPost{
+		Author:      obj.Username,
+		Text:        obj.ContentDesc,
+		ContentType: obj.ContentObject.ContentStyle,
+		Title:       obj.ContentObject.Title,
+		URL:         obj.ContentObject.ContentUrl,
+		Xml:         data,
+	}
+	if obj.Id != "" {
+		id, err := strconv.ParseUint(strings.TrimSpace(obj.Id), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: id %q", ErrInvalidMoment, obj.Id)
+		}
+		post.Id = id
+	}
+	if obj.CreateTime > 0 {
+		post.CreateTime = time.Unix(obj.CreateTime, 0)
+	}
+	for _, m := range obj.ContentObject.Media {
+		width, _ := strconv.Atoi(m.Size.Width)
+		height, _ := strconv.Atoi(m.Size.Height)
+		post.Media = append(post.Media, MomentMedia{
+			Id:     strings.TrimSpace(m.Id),
+			Type:   m.Type,
+			URL:    strings.TrimSpace(m.URL),
+			Thumb:  strings.TrimSpace(m.Thumb),
+			Width:  width,
+			Height: height,
+		})
+	}
+	if loc := obj.Location; loc.PoiName != "" || loc.Latitude != "" {
+		lat, _ := strconv.ParseFloat(loc.Latitude, 64)
+		lng, _ := strconv.ParseFloat(loc.Longitude, 64)
+		post.Location = &MomentLocation{PoiName: loc.PoiName, Address: loc.PoiAddress, City: loc.City, Latitude: lat, Longitude: lng}
+	}
+	return post, nil
+}
+
+// momentFeed 朋友圈动态通道，按动态 id 去重（翻页刷新会重复推送同一条动态）
+type momentFeed struct {
+	ch     chan *MomentPost
+	mu     sync.Mutex
+	seen   map[uint64]struct{}
+	order  []uint64 // 先进先出淘汰最早的 id
+	logger Logger
+}
+
+func newMomentFeed(size int, logger Logger) *momentFeed {
+	return &momentFeed{
+		ch:     make(chan *MomentPost, size),
+		seen:   make(map[uint64]struct{}),
+		logger: logger,
+	}
+}
+
+// mark 记录动态 id，已存在时返回 false
+func (f *momentFeed) mark(id uint64) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.seen[id]; ok {
+		return false
+	}
+	if len(f.order) >= momentSeenLimit {
+		delete(f.seen, f.order[0])
+		f.order = f.order[1:]
+	}
+	f.seen[id] = struct{}{}
+	f.order = append(f.order, id)
+	return true
+}
+
+func (f *momentFeed) unmark(id uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.seen, id)
+	for i, seen := range f.order {
+		if seen == id {
+			f.order = append(f.order[:i], f.order[i+1:]...)
+			return
+		}
+	}
+}
+
+// put 解析并投递朋友圈消息，重复的动态直接忽略
+func (f *momentFeed) put(msg *wcf.WxMsg) error {
+	data := msg.GetXml()
+	if data == "" {
+		data = msg.GetContent()
+	}
+	post, err := ParseMoment(data)
+	if err != nil {
+		return err
+	}
+	post.MessageId = msg.GetId()
+	if post.Id == 0 {
+		post.Id = msg.GetId()
+	}
+	if post.Author == "" {
+		post.Author = msg.GetSender()
+	}
+	if post.CreateTime.IsZero() && msg.GetTs() > 0 {
+		post.CreateTime = time.Unix(int64(msg.GetTs()), 0)
+	}
+	post.ReceivedAt = time.Now()
+	if !f.mark(post.Id) {
+		f.logger.Debug("duplicated moment skipped", map[string]interface{}{"id": post.Id})
+		return nil
+	}
+	select {
+	case f.ch <- post:
+		return nil
+	default:
+		f.unmark(post.Id) // 未投递，允许之后刷新时再次推送
+		return fmt.Errorf("moment %d: %w", post.Id, ErrBufferFull)
+	}
+}
+
+// GetMomentChan 返回朋友圈动态的管道，与 GetMsgChan 分开，动态按 id 去重
+func (c *Client) GetMomentChan() <-chan *MomentPost {
+	return c.moments.ch
+}
+
+// RefreshMoments 刷新朋友圈 <开始 id，0 为最新页，传入已收到的最早动态 id 可继续加载更早的动态>
+// 刷新结果通过 GetMomentChan 异步推送
+func (c *Client) RefreshMoments(ctx context.Context, id uint64) error {
+	if _, err := c.wxClient.RefreshPYQPageCtx(ctx, id); err != nil {
+		return fmt.Errorf("wxClient.RefreshPYQ: %w", err)
+	}
+	return nil
+}
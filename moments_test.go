@@ -0,0 +1,85 @@
+package wcf_rpc_sdk
+
+import (
+	"context"
+	"errors"
+	"github.com/Clov614/wcf-rpc-sdk/internal/wcf"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const momentXml = `<TimelineObject><id>14123456789012345678</id><username>wxid_friend</username><createTime>1700000000</createTime>` +
+	`<contentDesc>今天天气不错</contentDesc>` +
+	`<location poiName="西湖" poiAddress="杭州市西湖区" city="杭州" latitude="30.25" longitude="120.14"></location>` +
+	`<ContentObject><contentStyle>1</contentStyle><title></title><contentUrl></contentUrl><mediaList>` +
+	`<media><id>1412</id><type>2</type><url type="1">http://img/1</url><thumb type="1">http://thumb/1</thumb><size width="1080" height="1920" totalSize="1"></size></media>` +
+	`</mediaList></ContentObject></TimelineObject>`
+
+func TestParseMoment(t *testing.T) {
+	post, err := ParseMoment(momentXml)
+	if err != nil {
+		t.Fatalf("ParseMoment() error = %v", err)
+	}
+	if post.Id != 14123456789012345678 || post.Author != "wxid_friend" || post.Text != "今天天气不错" || post.ContentType != 1 {
+		t.Errorf("ParseMoment() = %+v", post)
+	}
+	if !post.CreateTime.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("CreateTime = %v", post.CreateTime)
+	}
+	wantMedia := []MomentMedia{{Id: "1412", Type: 2, URL: "http://img/1", Thumb: "http://thumb/1", Width: 1080, Height: 1920}}
+	if !reflect.DeepEqual(post.Media, wantMedia) {
+		t.Errorf("Media = %+v, want %+v", post.Media, wantMedia)
+	}
+	wantLoc := &MomentLocation{PoiName: "西湖", Address: "杭州市西湖区", City: "杭州", Latitude: 30.25, Longitude: 120.14}
+	if !reflect.DeepEqual(post.Location, wantLoc) {
+		t.Errorf("Location = %+v, want %+v", post.Location, wantLoc)
+	}
+
+	if _, err = ParseMoment("<msg></msg>"); !errors.Is(err, ErrInvalidMoment) {
+		t.Errorf("ParseMoment() error = %v, want ErrInvalidMoment", err)
+	}
+}
+
+func TestClient_Moments(t *testing.T) {
+	cli, srv := newOfflineClient(t)
+	if err := cli.Run(false); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if err := cli.RefreshMoments(context.Background(), 14123456789012345678); err != nil {
+		t.Fatalf("RefreshMoments() error = %v", err)
+	}
+	if reqs := srv.RequestsOf(wcf.Functions_FUNC_REFRESH_PYQ); len(reqs) != 1 || reqs[0].GetUi64() != 14123456789012345678 {
+		t.Errorf("refresh requests = %v", reqs)
+	}
+	// 翻页刷新会重复推送同一条动态
+	for id := uint64(1); id <= 2; id++ {
+		if err := srv.Push(&wcf.WxMsg{Id: id, Type: uint32(MsgTypeMoments), Sender: "wxid_friend", Xml: momentXml}); err != nil {
+			t.Fatalf("Push() error = %v", err)
+		}
+	}
+	_ = srv.Push(&wcf.WxMsg{Id: 3, Type: uint32(MsgTypeText), Sender: "wxid_friend", Content: "hi"})
+
+	if msg := recvMsg(t, cli); msg.Type != MsgTypeText {
+		t.Errorf("GetMsgChan() received type %v, want text only", msg.Type)
+	}
+	select {
+	case post := <-cli.GetMomentChan():
+		if post.Id != 14123456789012345678 || (post.MessageId != 1 && post.MessageId != 2) { // 消息池并发处理，先到者被投递
+			t.Errorf("moment = %+v", post)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("等待朋友圈动态超时")
+	}
+	select {
+	case post := <-cli.GetMomentChan():
+		t.Errorf("duplicated moment delivered: %+v", post)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	srv.SetStatus(wcf.Functions_FUNC_REFRESH_PYQ, 0)
+	var statusErr *ErrStatus
+	if err := cli.RefreshMoments(context.Background(), 0); !errors.As(err, &statusErr) {
+		t.Errorf("RefreshMoments() error = %v, want *ErrStatus", err)
+	}
+}
@@ -0,0 +1,73 @@
+// Package wcf_rpc_sdk
+// @Author Clover
+// @Data 2026/10/16 下午11:20:00
+// @Desc 图片文字识别
+package wcf_rpc_sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultOCRTimeout = 10 * time.Second // ctx 未设置 deadline 时等待识别结果的时间
+	ocrRetryInterval  = time.Second      // 结果未就绪时的重试间隔，与官方客户端一致
+)
+
+var (
+	ErrNotImage    = errors.New("the message is not an image")
+	ErrOCRNotReady = errors.New("ocr result not ready") // 超时前服务端仍未给出结果
+)
+
+// OcrResult 文字识别结果
+type OcrResult struct {
+	Text  string   `json:"text"`  // 原始识别结果
+	Lines []string `json:"lines"` // 按行拆分并去除空行
+}
+
+func newOcrResult(text string) *OcrResult {
+	res := &OcrResult{Text: text}
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			res.Lines = append(res.Lines, line)
+		}
+	}
+	return res
+}
+
+// OCR 识别图片消息中的文字，结果未就绪时按间隔重试直到 ctx 结束；需要微信已自动下载该图片
+func (c *Client) OCR(ctx context.Context, msg *Message) (*OcrResult, error) {
+	if msg == nil || msg.Type != MsgTypeImage {
+		return nil, ErrNotImage
+	}
+	extra := msg.Extra
+	if extra == "" && msg.FileInfo != nil {
+		extra = msg.FileInfo.FilePath
+	}
+	if extra == "" {
+		return nil, fmt.Errorf("%w: missing image path", ErrNotImage)
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultOCRTimeout)
+		defer cancel()
+	}
+	for attempt := 1; ; attempt++ {
+		ocr, err := c.wxClient.ExecOCRCtx(ctx, extra)
+		if err != nil {
+			return nil, fmt.Errorf("wxClient.ExecOCR: %w", err)
+		}
+		if ocr.GetStatus() == 0 {
+			return newOcrResult(ocr.GetResult()), nil
+		}
+		c.logger.Debug("ocr result not ready", map[string]interface{}{"messageId": msg.MessageId, "status": ocr.GetStatus(), "attempt": attempt})
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: status %d: %w", ErrOCRNotReady, ocr.GetStatus(), ctx.Err())
+		case <-time.After(ocrRetryInterval):
+		}
+	}
+}
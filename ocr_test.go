@@ -0,0 +1,54 @@
+package wcf_rpc_sdk
+
+import (
+	"context"
+	"errors"
+	"github.com/Clov614/wcf-rpc-sdk/internal/wcf"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClient_OCR(t *testing.T) {
+	cli, srv := newOfflineClient(t)
+	calls := 0
+	srv.Handle(wcf.Functions_FUNC_EXEC_OCR, func(req *wcf.Request) *wcf.Response {
+		calls++
+		ocr := &wcf.OcrMsg{Status: 1}
+		if calls == 2 {
+			ocr = &wcf.OcrMsg{Status: 0, Result: "第一行\n\n  第二行 \n"}
+		}
+		return &wcf.Response{Msg: &wcf.Response_Ocr{Ocr: ocr}}
+	})
+
+	msg := &Message{Type: MsgTypeImage, Extra: `C:\wx\Image\a.dat`}
+	res, err := cli.OCR(context.Background(), msg)
+	if err != nil {
+		t.Fatalf("OCR() error = %v", err)
+	}
+	if want := []string{"第一行", "第二行"}; !reflect.DeepEqual(res.Lines, want) {
+		t.Errorf("OCR() lines = %q, want %q", res.Lines, want)
+	}
+	reqs := srv.RequestsOf(wcf.Functions_FUNC_EXEC_OCR)
+	if len(reqs) != 2 || reqs[0].GetStr() != msg.Extra {
+		t.Errorf("ocr requests = %v", reqs)
+	}
+}
+
+func TestClient_OCRErrors(t *testing.T) {
+	cli, srv := newOfflineClient(t)
+	if _, err := cli.OCR(context.Background(), &Message{Type: MsgTypeText, Content: "hi"}); !errors.Is(err, ErrNotImage) {
+		t.Errorf("OCR(text) error = %v, want ErrNotImage", err)
+	}
+	if _, err := cli.OCR(context.Background(), &Message{Type: MsgTypeImage}); !errors.Is(err, ErrNotImage) {
+		t.Errorf("OCR(no path) error = %v, want ErrNotImage", err)
+	}
+
+	srv.SetStatus(wcf.Functions_FUNC_EXEC_OCR, -1)
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	_, err := cli.OCR(ctx, &Message{Type: MsgTypeImage, FileInfo: &FileInfo{FilePath: "C:/wx/Image/a.dat"}})
+	if !errors.Is(err, ErrOCRNotReady) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("OCR() error = %v, want ErrOCRNotReady", err)
+	}
+}
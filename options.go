@@ -0,0 +1,249 @@
+// Package wcf_rpc_sdk
+// @Author Clover
+// @Data 2026/10/16 下午8:30:00
+// @Desc 客户端配置项
+package wcf_rpc_sdk
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Clov614/wcf-rpc-sdk/internal/wcf"
+	"github.com/eatmoreapple/env"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultBufferSize             = 10               // 默认消息缓冲大小
+	DefaultContactRefreshInterval = 30 * time.Minute // 默认联系人缓存刷新间隔
+	DefaultSelfRefreshInterval    = 2 * time.Hour    // 默认个人信息刷新间隔
+)
+
+var ErrInvalidOption = errors.New("invalid option")
+
+// Transport RPC 传输协议
+type Transport string
+
+const (
+	TransportTCP Transport = "tcp"
+	TransportWS  Transport = "ws"
+)
+
+// OverflowPolicy 消息处理队列已满时的策略
+type OverflowPolicy = wcf.OverflowPolicy
+
+const (
+	OverflowBlock      = wcf.OverflowBlock      // 阻塞接收，直到队列有空位
+	OverflowDropOldest = wcf.OverflowDropOldest // 丢弃队列中最早的消息
+	OverflowDropNewest = wcf.OverflowDropNewest // 丢弃新到达的消息
+)
+
+// MsgPoolStats 消息处理协程池指标：队列深度、丢弃数等
+type MsgPoolStats = wcf.PoolStats
+
+// Option 客户端配置项，非法参数会使 New 返回 ErrInvalidOption
+type Option func(o *options) error
+
+type options struct {
+	addr                   string
+	transport              Transport
+	bufferSize             int
+	autoInject             bool
+	sdkDebug               bool
+	logger                 Logger
+	contactRefreshInterval time.Duration
+	selfRefreshInterval    time.Duration
+	rpcTimeout             time.Duration
+	msgPool                wcf.PoolConfig
+	transferPolicies       []TransferPolicy
+	attachmentWorkers      int
+	attachmentTimeout      time.Duration
+}
+
+func defaultOptions() *options {
+	return &options{
+		bufferSize:             DefaultBufferSize,
+		logger:                 defaultLogger{},
+		contactRefreshInterval: DefaultContactRefreshInterval,
+		selfRefreshInterval:    DefaultSelfRefreshInterval,
+		rpcTimeout:             -1, // 未设置时沿用 wcf.DefaultTimeout
+		msgPool:                wcf.DefaultPoolConfig(),
+		attachmentWorkers:      DefaultAttachmentWorkers,
+		attachmentTimeout:      DefaultAttachmentTimeout,
+	}
+}
+
+// WithAddr RPC 服务地址，如 tcp://127.0.0.1:10086，省略协议时使用 WithTransport 指定的协议；默认读取环境变量 TCP_ADDR
+func WithAddr(addr string) Option {
+	return func(o *options) error {
+		if strings.TrimSpace(addr) == "" {
+			return fmt.Errorf("%w: empty addr", ErrInvalidOption)
+		}
+		o.addr = addr
+		return nil
+	}
+}
+
+// WithTransport RPC 传输协议，默认 TransportTCP
+func WithTransport(t Transport) Option {
+	return func(o *options) error {
+		switch t {
+		case TransportTCP, TransportWS:
+			o.transport = t
+			return nil
+		}
+		return fmt.Errorf("%w: unsupported transport %q", ErrInvalidOption, t)
+	}
+}
+
+// WithBufferSize 消息通道缓冲大小
+func WithBufferSize(size int) Option {
+	return func(o *options) error {
+		if size < 0 {
+			return fmt.Errorf("%w: negative buffer size %d", ErrInvalidOption, size)
+		}
+		o.bufferSize = size
+		return nil
+	}
+}
+
+// WithAutoInject 是否自动注入微信（自动打开微信），仅 windows 有效
+func WithAutoInject(autoInject bool) Option {
+	return func(o *options) error {
+		o.autoInject = autoInject
+		return nil
+	}
+}
+
+// WithSDKDebug 注入时是否开启 sdk-debug
+func WithSDKDebug(sdkDebug bool) Option {
+	return func(o *options) error {
+		o.sdkDebug = sdkDebug
+		return nil
+	}
+}
+
+// WithLogger 自定义日志输出
+func WithLogger(logger Logger) Option {
+	return func(o *options) error {
+		if logger == nil {
+			return fmt.Errorf("%w: nil logger", ErrInvalidOption)
+		}
+		o.logger = logger
+		return nil
+	}
+}
+
+// WithContactRefreshInterval 联系人缓存刷新间隔
+func WithContactRefreshInterval(d time.Duration) Option {
+	return func(o *options) error {
+		if d <= 0 {
+			return fmt.Errorf("%w: contact refresh interval %v", ErrInvalidOption, d)
+		}
+		o.contactRefreshInterval = d
+		return nil
+	}
+}
+
+// WithSelfRefreshInterval 个人信息与通讯录刷新间隔
+func WithSelfRefreshInterval(d time.Duration) Option {
+	return func(o *options) error {
+		if d <= 0 {
+			return fmt.Errorf("%w: self refresh interval %v", ErrInvalidOption, d)
+		}
+		o.selfRefreshInterval = d
+		return nil
+	}
+}
+
+// WithRPCTimeout 单次 RPC 调用的默认超时，0 表示不限制（仍受 ctx 控制）
+func WithRPCTimeout(d time.Duration) Option {
+	return func(o *options) error {
+		if d < 0 {
+			return fmt.Errorf("%w: negative rpc timeout %v", ErrInvalidOption, d)
+		}
+		o.rpcTimeout = d
+		return nil
+	}
+}
+
+// WithMsgPool 消息处理协程池 <协程数> <待处理队列长度> <队列已满时的策略>，默认 8 个协程、队列 1024、满时阻塞
+func WithMsgPool(workers, queueSize int, overflow OverflowPolicy) Option {
+	return func(o *options) error {
+		cfg := wcf.PoolConfig{Workers: workers, QueueSize: queueSize, Overflow: overflow, Ordered: o.msgPool.Ordered}
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidOption, err)
+		}
+		o.msgPool = cfg
+		return nil
+	}
+}
+
+// WithMsgOrdering 同一会话（群聊按 RoomId，私聊按对方 wxid）的消息按到达顺序投递到 GetMsgChan，不同会话仍并行处理；
+// 时间戳早于上一条的消息计入 MsgPoolStats().OutOfOrder，与上一条 MessageId 相同的重复消息被跳过
+func WithMsgOrdering(ordered bool) Option {
+	return func(o *options) error {
+		o.msgPool.Ordered = ordered
+		return nil
+	}
+}
+
+// resolveAddr 补全并校验服务地址，消息端口为命令端口 +1
+func (o *options) resolveAddr() (string, error) {
+	addr := o.addr
+	if addr == "" {
+		addr = env.Name(ENVTcpAddr).StringOrElse(DefaultTcpAddr) // "tcp://127.0.0.1:10086"
+	}
+	transport := o.transport
+	if transport == "" {
+		transport = TransportTCP
+	}
+	if !strings.Contains(addr, "://") {
+		addr = string(transport) + "://" + addr
+	}
+	u, err := url.Parse(addr)
+	if err != nil {
+		return "", fmt.Errorf("%w: addr %q: %w", ErrInvalidOption, addr, err)
+	}
+	if o.transport != "" && Transport(u.Scheme) != o.transport {
+		return "", fmt.Errorf("%w: addr %q does not match transport %q", ErrInvalidOption, addr, o.transport)
+	}
+	if err = WithTransport(Transport(u.Scheme))(&options{}); err != nil {
+		return "", err
+	}
+	port, err := strconv.Atoi(u.Port())
+	if err != nil || port <= 0 || port >= 65535 {
+		return "", fmt.Errorf("%w: addr %q needs a port in 1-65534", ErrInvalidOption, addr)
+	}
+	return addr, nil
+}
+
+// WithTransferPolicy 自动收款策略，收到发给自己的待收款转账时，所有策略均返回 true 才自动收款；未设置时不自动收款
+func WithTransferPolicy(policies ...TransferPolicy) Option {
+	return func(o *options) error {
+		for _, policy := range policies {
+			if policy == nil {
+				return fmt.Errorf("%w: nil transfer policy", ErrInvalidOption)
+			}
+		}
+		o.transferPolicies = append(o.transferPolicies, policies...)
+		return nil
+	}
+}
+
+// WithAttachmentDownload 附件下载并发数与单个附件等待写入完成的超时
+func WithAttachmentDownload(workers int, timeout time.Duration) Option {
+	return func(o *options) error {
+		if workers < 1 {
+			return fmt.Errorf("%w: attachment workers must be positive, got %d", ErrInvalidOption, workers)
+		}
+		if timeout <= 0 {
+			return fmt.Errorf("%w: non-positive attachment timeout %v", ErrInvalidOption, timeout)
+		}
+		o.attachmentWorkers = workers
+		o.attachmentTimeout = timeout
+		return nil
+	}
+}
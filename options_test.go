@@ -0,0 +1,144 @@
+package wcf_rpc_sdk
+
+import (
+	"context"
+	"errors"
+	"github.com/Clov614/wcf-rpc-sdk/internal/wcf"
+	"github.com/Clov614/wcf-rpc-sdk/internal/wcftest"
+	"testing"
+	"time"
+)
+
+func TestOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"empty addr", []Option{WithAddr(" ")}},
+		{"unknown transport", []Option{WithTransport("udp")}},
+		{"negative buffer", []Option{WithBufferSize(-1)}},
+		{"nil logger", []Option{WithLogger(nil)}},
+		{"zero contact interval", []Option{WithContactRefreshInterval(0)}},
+		{"negative self interval", []Option{WithSelfRefreshInterval(-time.Second)}},
+		{"negative rpc timeout", []Option{WithRPCTimeout(-time.Second)}},
+		{"addr without port", []Option{WithAddr("tcp://127.0.0.1")}},
+		{"addr transport mismatch", []Option{WithAddr("ws://127.0.0.1:10086"), WithTransport(TransportTCP)}},
+		{"unsupported scheme", []Option{WithAddr("udp://127.0.0.1:10086")}},
+		{"no pool workers", []Option{WithMsgPool(0, 16, OverflowBlock)}},
+		{"no pool queue", []Option{WithMsgPool(2, 0, OverflowDropOldest)}},
+		{"unknown overflow", []Option{WithMsgPool(2, 16, OverflowPolicy(9))}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli, err := New(context.Background(), tt.opts...)
+			if !errors.Is(err, ErrInvalidOption) || cli != nil {
+				t.Errorf("New() = %v, %v, want ErrInvalidOption", cli, err)
+			}
+		})
+	}
+}
+
+func TestOptions_ResolveAddr(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"full", []Option{WithAddr("tcp://192.168.1.2:10086")}, "tcp://192.168.1.2:10086"},
+		{"host port", []Option{WithAddr("127.0.0.1:10010")}, "tcp://127.0.0.1:10010"},
+		{"host port ws", []Option{WithAddr("127.0.0.1:10010"), WithTransport(TransportWS)}, "ws://127.0.0.1:10010"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := defaultOptions()
+			for _, opt := range tt.opts {
+				if err := opt(o); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := o.resolveAddr()
+			if err != nil || got != tt.want {
+				t.Errorf("resolveAddr() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+// TestNew_MultiClient 同一进程中不同配置的多个客户端互不干扰
+func TestNew_MultiClient(t *testing.T) {
+	newServer := func(wxid string) *wcftest.Server {
+		srv, err := wcftest.NewServer()
+		if err != nil {
+			t.Fatalf("wcftest.NewServer() error = %v", err)
+		}
+		t.Cleanup(func() { _ = srv.Close() })
+		srv.SetUserInfo(&wcf.UserInfo{Wxid: wxid})
+		return srv
+	}
+	srvA, srvB := newServer("wxid_a"), newServer("wxid_b")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cliA, err := New(ctx, WithAddr(srvA.Addr()), WithBufferSize(1), WithRPCTimeout(time.Second), WithMsgPool(2, 16, OverflowDropNewest))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer cliA.Close()
+	cliB, err := New(ctx, WithAddr(srvB.Addr()), WithSelfRefreshInterval(time.Minute), WithContactRefreshInterval(time.Minute))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer cliB.Close()
+
+	if cap(cliA.msgBuffer.msgCH) != 1 || cap(cliB.msgBuffer.msgCH) != DefaultBufferSize {
+		t.Errorf("buffer size = %d, %d", cap(cliA.msgBuffer.msgCH), cap(cliB.msgBuffer.msgCH))
+	}
+	if cliB.selfRefreshInterval != time.Minute || cliA.selfRefreshInterval != DefaultSelfRefreshInterval {
+		t.Errorf("self refresh interval = %v, %v", cliA.selfRefreshInterval, cliB.selfRefreshInterval)
+	}
+	if a, b := cliA.MsgPoolStats(), cliB.MsgPoolStats(); a.Workers != 2 || a.QueueCap != 16 || b.QueueCap != 1024 {
+		t.Errorf("MsgPoolStats() = %+v, %+v", a, b)
+	}
+	for want, cli := range map[string]*Client{"wxid_a": cliA, "wxid_b": cliB} {
+		if info, ok := cli.GetSelfInfo(); !ok || info.Wxid != want {
+			t.Errorf("GetSelfInfo() = %v, %v, want %s", info, ok, want)
+		}
+	}
+}
+
+// TestNew_MsgOrdering 同一会话的消息按到达顺序进入 GetMsgChan
+func TestNew_MsgOrdering(t *testing.T) {
+	srv, err := wcftest.NewServer()
+	if err != nil {
+		t.Fatalf("wcftest.NewServer() error = %v", err)
+	}
+	t.Cleanup(func() { _ = srv.Close() })
+	srv.SetUserInfo(&wcf.UserInfo{Wxid: "wxid_self"})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cli, err := New(ctx, WithAddr(srv.Addr()), WithBufferSize(64), WithMsgPool(4, 64, OverflowBlock), WithMsgOrdering(true))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer cli.Close()
+	if err = cli.Run(false); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	senders := []string{"wxid_a", "wxid_b"}
+	const total = 20
+	for i := 1; i <= total; i++ {
+		msg := &wcf.WxMsg{Id: uint64(i), Ts: uint32(1736867600 + i), Type: uint32(MsgTypeText), Sender: senders[i%2], Content: "hi"}
+		if err = srv.Push(msg); err != nil {
+			t.Fatalf("Push() error = %v", err)
+		}
+	}
+	last := make(map[string]uint64)
+	for i := 0; i < total; i++ {
+		msg := recvMsg(t, cli)
+		if msg.MessageId < last[msg.WxId] {
+			t.Errorf("%s: message %d after %d", msg.WxId, msg.MessageId, last[msg.WxId])
+		}
+		last[msg.WxId] = msg.MessageId
+	}
+}
@@ -0,0 +1,55 @@
+// Package wcf_rpc_sdk
+// @Author Clover
+// @Data 2026/10/17 上午4:00:00
+// @Desc 拍一拍消息解析与发送
+package wcf_rpc_sdk
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// PatEvent 拍一拍
+type PatEvent struct {
+	From     string `json:"from"`              // 拍人者 wxid
+	Patted   string `json:"patted"`            // 被拍者 wxid
+	RoomId   string `json:"room_id,omitempty"` // 群聊中拍一拍时的群 id
+	Template string `json:"template"`          // 提示模板，如 "${wxid_a}" 拍了拍 "${wxid_b}"
+}
+
+// patXml sysmsg 中的 pat
+type patXml struct {
+	FromUsername   string `xml:"fromusername"`
+	ChatUsername   string `xml:"chatusername"`
+	PattedUsername string `xml:"pattedusername"`
+	Template       string `xml:"template"`
+}
+
+// parsePatEvent 解析 <sysmsg type="pat">，其他消息返回 nil
+func parsePatEvent(content string) (*PatEvent, error) {
+	sm, err := parseSysMsg(content)
+	if err != nil || sm == nil || sm.Type != "pat" {
+		return nil, err
+	}
+	pat := &PatEvent{From: sm.Pat.FromUsername, Patted: sm.Pat.PattedUsername, Template: sm.Pat.Template}
+	if strings.HasSuffix(sm.Pat.ChatUsername, "@chatroom") {
+		pat.RoomId = sm.Pat.ChatUsername
+	}
+	return pat, nil
+}
+
+// Pat 拍一拍群成员 <roomid> <wxid>
+func (c *Client) Pat(roomId, wxid string) error {
+	return c.PatCtx(context.Background(), roomId, wxid)
+}
+
+// PatCtx 同 Pat，支持 ctx 取消与超时
+func (c *Client) PatCtx(ctx context.Context, roomId, wxid string) error {
+	res, err := c.wxClient.SendPatCtx(ctx, roomId, wxid)
+	if err != nil {
+		c.logger.Debug("wxClient.SendPat", map[string]interface{}{"res": res, "roomId": roomId, "wxid": wxid})
+		return fmt.Errorf("wxClient.SendPat: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package wcf_rpc_sdk
+
+import (
+	"github.com/Clov614/wcf-rpc-sdk/internal/wcf"
+	"testing"
+)
+
+const patContent = `45959390469@chatroom:
+<sysmsg type="pat">
+<pat>
+  <fromusername>wxid_friend</fromusername>
+  <chatusername>45959390469@chatroom</chatusername>
+  <pattedusername>` + offlineSelfWxid + `</pattedusername>
+  <patsuffix><![CDATA[]]></patsuffix>
+  <patsuffixversion>0</patsuffixversion>
+  <template><![CDATA["${wxid_friend}" 拍了拍我]]></template>
+</pat>
+</sysmsg>`
+
+func TestParsePatEvent(t *testing.T) {
+	got, err := parsePatEvent(patContent)
+	if err != nil {
+		t.Fatalf("parsePatEvent() error = %v", err)
+	}
+	want := PatEvent{From: "wxid_friend", Patted: offlineSelfWxid, RoomId: "45959390469@chatroom", Template: `"${wxid_friend}" 拍了拍我`}
+	if got == nil || *got != want {
+		t.Errorf("parsePatEvent() = %+v, want %+v", got, want)
+	}
+	if got, err = parsePatEvent(revokeSysMsg); got != nil || err != nil {
+		t.Errorf("parsePatEvent(revoke) = %+v, %v, want nil", got, err)
+	}
+}
+
+func TestClient_Pat(t *testing.T) {
+	cli, srv := newOfflineClient(t)
+	if err := cli.Run(false); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	_ = srv.Push(&wcf.WxMsg{Id: 1, Type: uint32(MsgTypeRevoke), Sender: "wxid_friend", Content: patContent})
+	msg := recvMsg(t, cli)
+	if msg.Type != MsgTypePat || msg.Pat == nil || msg.Pat.From != "wxid_friend" || msg.RoomId != "45959390469@chatroom" || msg.GroupEvent != nil {
+		t.Fatalf("Type = %v, Pat = %+v, RoomId = %q, GroupEvent = %#v", msg.Type, msg.Pat, msg.RoomId, msg.GroupEvent)
+	}
+
+	if err := cli.Pat(msg.Pat.RoomId, msg.Pat.From); err != nil {
+		t.Fatalf("Pat() error = %v", err)
+	}
+	reqs := srv.RequestsOf(wcf.Functions_FUNC_SEND_PAT_MSG)
+	if len(reqs) != 1 || reqs[0].GetPm().GetRoomid() != "45959390469@chatroom" || reqs[0].GetPm().GetWxid() != "wxid_friend" {
+		t.Fatalf("send pat requests = %v", reqs)
+	}
+	srv.SetStatus(wcf.Functions_FUNC_SEND_PAT_MSG, 0)
+	if err := cli.Pat("45959390469@chatroom", "wxid_friend"); err == nil {
+		t.Errorf("Pat() with failed status error = nil")
+	}
+}
@@ -0,0 +1,173 @@
+// Package wcf_rpc_sdk
+// @Author Clover
+// @Data 2026/10/17 上午4:20:00
+// @Desc 红包、红包封面消息与领取红包通知的解析
+package wcf_rpc_sdk
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	appMsgTypeRedPacket      = 2001 // appmsg 中红包的 type
+	appMsgTypeRedPacketCover = 2003 // appmsg 中红包封面的 type
+
+	msgTypeRedPacketRaw MsgType = 436207665 // 微信红包的原始消息类型，解析后统一为 MsgTypeRedPacket
+)
+
+// RedPacketKind 红包消息种类
+type RedPacketKind int
+
+const (
+	RedPacketNormal   RedPacketKind = iota // 红包
+	RedPacketCover                         // 红包封面
+	RedPacketReceived                      // 领取红包的系统通知
+)
+
+var redPacketKindNames = map[RedPacketKind]string{
+	RedPacketNormal:   "红包",
+	RedPacketCover:    "红包封面",
+	RedPacketReceived: "领取红包",
+}
+
+func (k RedPacketKind) String() string {
+	if name, ok := redPacketKindNames[k]; ok {
+		return name
+	}
+	return strconv.Itoa(int(k))
+}
+
+// RedPacketMsg 红包消息，仅用于识别与统计，SDK 不提供拆红包
+type RedPacketMsg struct {
+	Kind      RedPacketKind `json:"kind"`
+	Sender    EventMember   `json:"sender"`             // 发红包的人，红包消息中仅有 wxid
+	Receiver  *EventMember  `json:"receiver,omitempty"` // 领取红包的人，仅领取通知中有
+	Greeting  string        `json:"greeting,omitempty"` // 祝福语
+	SceneId   int           `json:"scene_id,omitempty"` // 场景 id，如 1001 群红包、1002 个人红包
+	SceneText string        `json:"scene_text,omitempty"`
+	NativeURL string        `json:"native_url,omitempty"` // wxpay:// 开头的领取链接
+	SendId    string        `json:"send_id,omitempty"`    // 红包 id，可关联红包消息与领取通知
+	RoomId    string        `json:"room_id,omitempty"`
+}
+
+// redPacketXml 红包 appmsg
+type redPacketXml struct {
+	FromUsername string `xml:"fromusername"`
+	AppMsg       struct {
+		Title     string `xml:"title"`
+		Type      int    `xml:"type"`
+		WcPayInfo struct {
+			ReceiverTitle string `xml:"receivertitle"`
+			SceneText     string `xml:"scenetext"`
+			SceneId       int    `xml:"sceneid"`
+			NativeURL     string `xml:"nativeurl"`
+			PayMsgId      string `xml:"paymsgid"`
+		} `xml:"wcpayinfo"`
+	} `xml:"appmsg"`
+}
+
+// parseRedPacketMsg 解析 appmsg type 为 2001、2003 的红包与红包封面，其他消息返回 nil
+func parseRedPacketMsg(content string) (*RedPacketMsg, error) {
+	var rx redPacketXml
+	if err := xml.Unmarshal([]byte(content), &rx); err != nil {
+		return nil, fmt.Errorf("xml.Unmarshal red packet: %w", err)
+	}
+	a := rx.AppMsg
+	rp := &RedPacketMsg{
+		Sender:    EventMember{Wxid: rx.FromUsername},
+		Greeting:  a.WcPayInfo.ReceiverTitle,
+		SceneId:   a.WcPayInfo.SceneId,
+		SceneText: a.WcPayInfo.SceneText,
+		NativeURL: strings.TrimSpace(a.WcPayInfo.NativeURL),
+		SendId:    a.WcPayInfo.PayMsgId,
+	}
+	switch a.Type {
+	case appMsgTypeRedPacket:
+		rp.Kind = RedPacketNormal
+	case appMsgTypeRedPacketCover:
+		rp.Kind = RedPacketCover
+	default:
+		return nil, nil
+	}
+	if rp.Greeting == "" {
+		rp.Greeting = a.Title
+	}
+	if u, err := url.Parse(rp.NativeURL); err == nil && rp.NativeURL != "" {
+		q := u.Query()
+		if id := q.Get("sendid"); id != "" {
+			rp.SendId = id
+		}
+		if rp.Sender.Wxid == "" {
+			rp.Sender.Wxid = q.Get("sendusername")
+		}
+	}
+	return rp, nil
+}
+
+var (
+	reRedPacketReceived = regexp.MustCompile(`^(你|"(.+?)")领取了(你|自己|"(.+?)")发?的红包`)
+	reRedPacketSendId   = regexp.MustCompile(`sendid=(\d+)`)
+	reXmlTag            = regexp.MustCompile(`<[^>]*>`)
+)
+
+// parseRedPacketNotice 解析领取红包的系统通知，如 "张三"领取了你的红包，其他消息返回 nil
+// 新版本中昵称以 $ 包裹，并带有 <_wc_custom_link_> 标签
+func parseRedPacketNotice(text string, resolve memberResolver) *RedPacketMsg {
+	sendId := ""
+	if s := reRedPacketSendId.FindStringSubmatch(text); s != nil {
+		sendId = s[1]
+	}
+	text = strings.TrimSpace(reXmlTag.ReplaceAllString(text, ""))
+	text = strings.ReplaceAll(text, "$", `"`)
+	s := reRedPacketReceived.FindStringSubmatch(text)
+	if s == nil {
+		return nil
+	}
+	receiver := resolve(eventName(s[1], s[2]))
+	sender := receiver
+	if s[3] != "自己" {
+		sender = resolve(eventName(s[3], s[4]))
+	}
+	return &RedPacketMsg{Kind: RedPacketReceived, Sender: sender, Receiver: &receiver, SendId: sendId}
+}
+
+// parseRedPacket 解析红包、红包封面消息与领取通知，识别为红包消息时统一类型为 MsgTypeRedPacket
+func (c *Client) parseRedPacket(m *Message) {
+	var (
+		rp  *RedPacketMsg
+		err error
+	)
+	switch m.Type {
+	case MsgTypeSystem:
+		rp = parseRedPacketNotice(m.Content, c.resolveMember(m))
+	case MsgTypeRedPacket, msgTypeRedPacketRaw, MsgTypeRedPacketCover, MsgTypeXML:
+		rp, err = parseRedPacketMsg(m.Content)
+		if err != nil {
+			c.logger.Debug("parseRedPacketMsg", map[string]interface{}{"err": err, "content": m.Content})
+			return
+		}
+		if rp == nil {
+			return
+		}
+		if m.Type == MsgTypeRedPacketCover {
+			rp.Kind = RedPacketCover
+		}
+		if rp.Kind == RedPacketNormal {
+			m.Type = MsgTypeRedPacket
+		} else {
+			m.Type = MsgTypeRedPacketCover
+		}
+		if rp.Sender.Wxid == "" {
+			rp.Sender.Wxid = m.WxId
+		}
+	}
+	if rp != nil {
+		rp.RoomId = m.RoomId
+		m.RedPacket = rp
+	}
+}
@@ -0,0 +1,99 @@
+package wcf_rpc_sdk
+
+import (
+	"github.com/Clov614/wcf-rpc-sdk/internal/wcf"
+	"reflect"
+	"testing"
+)
+
+const redPacketContent = `<msg>
+	<appmsg appid="" sdkver="">
+		<des><![CDATA[我给你发了一个红包，赶紧去拆!]]></des>
+		<url><![CDATA[https://wxapp.tenpay.com/mmpayhb/wxhb_personalreceive?showwxpaytitle=1&msgtype=1&channelid=1&sendid=1000039501202410177019587261293&ver=6&sign=abc]]></url>
+		<title><![CDATA[微信红包]]></title>
+		<type>2001</type>
+		<wcpayinfo>
+			<templateid><![CDATA[7a2a165d31da7fce6dd77e05c300028a]]></templateid>
+			<receivertitle><![CDATA[恭喜发财，大吉大利]]></receivertitle>
+			<sendertitle><![CDATA[恭喜发财，大吉大利]]></sendertitle>
+			<scenetext><![CDATA[微信红包]]></scenetext>
+			<nativeurl><![CDATA[wxpay://c2cbizmessagehandler/hongbao/receivehongbao?msgtype=1&channelid=1&sendid=1000039501202410177019587261293&sendusername=wxid_friend&ver=6&sign=abc]]></nativeurl>
+			<sceneid><![CDATA[1002]]></sceneid>
+			<innertype><![CDATA[0]]></innertype>
+			<paymsgid><![CDATA[1000039501202410177019587261293]]></paymsgid>
+		</wcpayinfo>
+	</appmsg>
+	<fromusername><![CDATA[wxid_friend]]></fromusername>
+</msg>`
+
+func TestParseRedPacketMsg(t *testing.T) {
+	got, err := parseRedPacketMsg(redPacketContent)
+	if err != nil {
+		t.Fatalf("parseRedPacketMsg() error = %v", err)
+	}
+	want := &RedPacketMsg{
+		Kind:      RedPacketNormal,
+		Sender:    EventMember{Wxid: "wxid_friend"},
+		Greeting:  "恭喜发财，大吉大利",
+		SceneId:   1002,
+		SceneText: "微信红包",
+		NativeURL: "wxpay://c2cbizmessagehandler/hongbao/receivehongbao?msgtype=1&channelid=1&sendid=1000039501202410177019587261293&sendusername=wxid_friend&ver=6&sign=abc",
+		SendId:    "1000039501202410177019587261293",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseRedPacketMsg() = %+v, want %+v", got, want)
+	}
+	if got, err = parseRedPacketMsg(`<msg><appmsg><title>封面</title><type>2003</type></appmsg><fromusername>wxid_a</fromusername></msg>`); err != nil || got == nil || got.Kind != RedPacketCover || got.Greeting != "封面" {
+		t.Errorf("parseRedPacketMsg(cover) = %+v, %v", got, err)
+	}
+	if got, err = parseRedPacketMsg(linkContent); got != nil || err != nil {
+		t.Errorf("parseRedPacketMsg(link) = %+v, %v, want nil", got, err)
+	}
+}
+
+func TestParseRedPacketNotice(t *testing.T) {
+	resolve := memberResolver(func(n string) EventMember {
+		if n == selfName {
+			return EventMember{Wxid: "wxid_self", NickName: n}
+		}
+		return EventMember{NickName: n}
+	})
+	self, zs := EventMember{Wxid: "wxid_self", NickName: selfName}, EventMember{NickName: "张三"}
+	tests := []struct {
+		text string
+		want *RedPacketMsg
+	}{
+		{`"张三"领取了你的红包`, &RedPacketMsg{Kind: RedPacketReceived, Sender: self, Receiver: &zs}},
+		{`你领取了"张三"的红包`, &RedPacketMsg{Kind: RedPacketReceived, Sender: zs, Receiver: &self}},
+		{`你领取了自己发的红包`, &RedPacketMsg{Kind: RedPacketReceived, Sender: self, Receiver: &self}},
+		{`<img src="SystemMessages_HongbaoIcon.png"/>  你领取了$张三$的<_wc_custom_link_ color="#FD9931" href="weixin://weixinhongbao/opendetail?sendid=1000039501202410177019587261293&sign=abc&ver=6">红包</_wc_custom_link_>`,
+			&RedPacketMsg{Kind: RedPacketReceived, Sender: zs, Receiver: &self, SendId: "1000039501202410177019587261293"}},
+		{`"张三"修改群名为“红包群”`, nil},
+	}
+	for _, tt := range tests {
+		if got := parseRedPacketNotice(tt.text, resolve); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseRedPacketNotice(%q) = %+v, want %+v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestClient_RedPacket(t *testing.T) {
+	cli, srv := newOfflineClient(t)
+	if err := cli.Run(false); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	room := "45959390469@chatroom"
+	for i, typ := range []MsgType{MsgTypeXML, msgTypeRedPacketRaw} {
+		_ = srv.Push(&wcf.WxMsg{Id: uint64(i + 1), Type: uint32(typ), IsGroup: true, Roomid: room, Sender: "wxid_friend", Content: redPacketContent})
+		msg := recvMsg(t, cli)
+		if msg.Type != MsgTypeRedPacket || msg.RedPacket == nil || msg.RedPacket.RoomId != room || msg.RedPacket.Greeting != "恭喜发财，大吉大利" {
+			t.Fatalf("type %d: Type = %v, RedPacket = %+v", typ, msg.Type, msg.RedPacket)
+		}
+	}
+
+	_ = srv.Push(&wcf.WxMsg{Id: 3, Type: uint32(MsgTypeSystem), Sender: "wxid_friend", Content: `"friend"领取了你的红包`})
+	msg := recvMsg(t, cli)
+	if rp := msg.RedPacket; msg.Type != MsgTypeSystem || rp == nil || rp.Kind != RedPacketReceived || rp.Sender.Wxid != offlineSelfWxid || msg.GroupEvent != nil {
+		t.Fatalf("Type = %v, RedPacket = %+v, GroupEvent = %#v", msg.Type, rp, msg.GroupEvent)
+	}
+}
@@ -5,7 +5,7 @@
 package wcf_rpc_sdk
 
 import (
-	"github.com/Clov614/logging"
+	"context"
 	"github.com/Clov614/wcf-rpc-sdk/internal/wcf"
 	"path/filepath"
 	"strings"
@@ -23,6 +23,7 @@ type Self struct { // 机器人自己
 	Rooms   ChatRoomMp `json:"-"` // 加入的群列表
 	GHs     GHMp       `json:"-"` // 关注的公众号列表
 	mu      sync.RWMutex
+	logger  Logger
 }
 
 type SelfInfo struct { // 保护隐藏self信息
@@ -34,7 +35,7 @@ type SelfInfo struct { // 保护隐藏self信息
 }
 
 func NewSelf(cli *wcf.Client) *Self {
-	return &Self{cli: cli, Friends: make(FriendMp), Rooms: make(ChatRoomMp), GHs: make(GHMp)}
+	return &Self{cli: cli, Friends: make(FriendMp), Rooms: make(ChatRoomMp), GHs: make(GHMp), logger: defaultLogger{}}
 }
 
 // GetSelfInfo 获取个人账号信息 <getLatest true: 缓存获取到时是否异步获取>
@@ -51,16 +52,24 @@ func (s *Self) GetSelfInfo() (info SelfInfo, ok bool) {
 
 func (s *Self) getSelfInfo(getLatest bool) SelfInfo {
 	if getLatest {
-		info := s.cli.GetUserInfo()
+		info, err := s.cli.GetUserInfo()
+		if err != nil {
+			s.logger.Debug("self.getSelfInfo() s.cli.GetUserInfo err", map[string]interface{}{"err": err.Error()})
+			return SelfInfo{}
+		}
 		if info == nil || info.Wxid == "" {
 			return SelfInfo{}
 		}
+		s.mu.Lock()
 		s.Wxid = info.Wxid
 		s.Name = info.Name
 		s.Home = info.Home
 		s.Mobile = info.Mobile
 		s.FileStoragePath = filepath.Join(info.Home, info.Wxid, "FileStorage")
+		s.mu.Unlock()
 	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	return SelfInfo{
 		Wxid:            s.Wxid,
 		Name:            s.Name,
@@ -135,13 +144,17 @@ func (s *Self) IsSendByFriend(wxid string) (isFriend bool) {
 
 func (s *Self) UpdateInfo() (success bool) {
 	if !s.mu.TryLock() {
-		logging.Debug("try UpdateInfo give up! cause: Busy")
+		s.logger.Debug("try UpdateInfo give up! cause: Busy")
 		return false
 	}
 	defer s.mu.Unlock()
-	info := s.cli.GetUserInfo()
+	info, err := s.cli.GetUserInfo()
+	if err != nil {
+		s.logger.Error(err, "self.UpdateInfo() s.cli.GetUserInfo err")
+		return false
+	}
 	if info == nil {
-		logging.Debug("self.UpdateInfo() s.cli.GetUserInfo nil")
+		s.logger.Debug("self.UpdateInfo() s.cli.GetUserInfo nil")
 		return false
 	}
 	s.Wxid = info.Wxid
@@ -154,11 +167,15 @@ func (s *Self) UpdateInfo() (success bool) {
 
 func (s *Self) UpdateContact() (success bool) {
 	if !s.mu.TryLock() {
-		logging.Debug("try UpdateContact failed cause: Busy!")
+		s.logger.Debug("try UpdateContact failed cause: Busy!")
 		return false
 	}
 	defer s.mu.Unlock()
-	contacts := s.cli.GetContacts()
+	contacts, err := s.cli.GetContacts()
+	if err != nil {
+		s.logger.Error(err, "self.UpdateContact() s.cli.GetContacts err")
+		return false
+	}
 	for _, ct := range contacts {
 		u := ct2user(ct)
 		switch true {
@@ -174,11 +191,19 @@ func (s *Self) UpdateContact() (success bool) {
 }
 
 // ChatRooms 获取通讯录所有群聊
-func (s *Self) ChatRooms() ([]ChatRoom, bool) {
+func (s *Self) ChatRooms() ([]ChatRoom, error) {
+	return s.ChatRoomsCtx(context.Background())
+}
+
+// ChatRoomsCtx 同 ChatRooms，支持 ctx 取消与超时
+func (s *Self) ChatRoomsCtx(ctx context.Context) ([]ChatRoom, error) {
 	// 不走缓存
 	s.mu.Lock()
-	contacts := s.cli.GetContacts()
+	contacts, err := s.cli.GetContactsCtx(ctx)
 	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
 	var chatRooms = make([]ChatRoom, 0, len(contacts))
 	for _, ct := range contacts {
 		if isChatRoomType(ct.Wxid) {
@@ -186,17 +211,25 @@ func (s *Self) ChatRooms() ([]ChatRoom, bool) {
 			chatRooms = append(chatRooms, ChatRoom{User: u, RoomID: u.Wxid})
 		}
 	}
-	return chatRooms, true
+	return chatRooms, nil
 }
 
 // CtFriends 获取通讯录所有好友
-func (s *Self) CtFriends() ([]Friend, bool) {
+func (s *Self) CtFriends() ([]Friend, error) {
+	return s.CtFriendsCtx(context.Background())
+}
+
+// CtFriendsCtx 同 CtFriends，支持 ctx 取消与超时
+func (s *Self) CtFriendsCtx(ctx context.Context) ([]Friend, error) {
 	//if !s.mu.TryLock() { // 不走缓存
 	//	return nil, false
 	//}
 	s.mu.Lock()
-	contacts := s.cli.GetContacts()
+	contacts, err := s.cli.GetContactsCtx(ctx)
 	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
 	var friends = make([]Friend, 0, len(contacts))
 	for _, ct := range contacts {
 		if isFriendType(ct.Wxid) {
@@ -204,15 +237,23 @@ func (s *Self) CtFriends() ([]Friend, bool) {
 			friends = append(friends, Friend(u))
 		}
 	}
-	return friends, true
+	return friends, nil
 }
 
 // CtGHs 获取通讯录所有公众号
-func (s *Self) CtGHs() ([]GH, bool) {
+func (s *Self) CtGHs() ([]GH, error) {
+	return s.CtGHsCtx(context.Background())
+}
+
+// CtGHsCtx 同 CtGHs，支持 ctx 取消与超时
+func (s *Self) CtGHsCtx(ctx context.Context) ([]GH, error) {
 	// 不走缓存
 	s.mu.Lock()
-	contacts := s.cli.GetContacts()
+	contacts, err := s.cli.GetContactsCtx(ctx)
 	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
 	var ghs = make([]GH, 0, len(contacts))
 	for _, ct := range contacts {
 		if isGHType(ct.Wxid) {
@@ -220,7 +261,7 @@ func (s *Self) CtGHs() ([]GH, bool) {
 			ghs = append(ghs, GH(u))
 		}
 	}
-	return ghs, true
+	return ghs, nil
 }
 
 func ct2user(ct *wcf.RpcContact) User {
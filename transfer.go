@@ -0,0 +1,193 @@
+// Package wcf_rpc_sdk
+// @Author Clover
+// @Data 2026/10/17 上午1:10:00
+// @Desc 转账消息解析、收款与自动收款策略
+package wcf_rpc_sdk
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrNotTransfer        = errors.New("the message is not a transfer")
+	ErrTransferNotPending = errors.New("the transfer is not pending")
+)
+
+// TransferStatus 转账状态，对应 wcpayinfo 中的 paysubtype
+type TransferStatus int
+
+const (
+	TransferUnknown  TransferStatus = 0
+	TransferPending  TransferStatus = 1 // 待收款
+	TransferReceived TransferStatus = 3 // 已收款
+	TransferRefunded TransferStatus = 4 // 已退还
+	TransferExpired  TransferStatus = 5 // 过期退还
+)
+
+var transferStatusNames = map[TransferStatus]string{
+	TransferUnknown:  "未知",
+	TransferPending:  "待收款",
+	TransferReceived: "已收款",
+	TransferRefunded: "已退还",
+	TransferExpired:  "过期退还",
+}
+
+func (s TransferStatus) String() string {
+	if name, ok := transferStatusNames[s]; ok {
+		return name
+	}
+	return strconv.Itoa(int(s))
+}
+
+// TransferMsg 转账消息
+type TransferMsg struct {
+	Amount        int64          `json:"amount"`                  // 金额，单位分
+	AmountKnown   bool           `json:"amount_known"`            // AmountText 能否解析为金额，否则 Amount 为 0
+	AmountText    string         `json:"amount_text"`             // 原始金额描述，如 ￥0.01
+	Memo          string         `json:"memo,omitempty"`          // 转账说明
+	TransferId    string         `json:"transfer_id"`             // transferid
+	TransactionId string         `json:"transaction_id"`          // transactionid
+	Status        TransferStatus `json:"status"`                  // 转账状态
+	Payer         string         `json:"payer,omitempty"`         // 付款人 wxid
+	Receiver      string         `json:"receiver,omitempty"`      // 收款人 wxid
+	AutoAccepted  bool           `json:"auto_accepted,omitempty"` // 是否已由自动收款策略收款
+}
+
+// TransferPolicy 自动收款策略，返回 true 表示同意收款
+type TransferPolicy func(msg *Message) bool
+
+// TransferFromFriends 仅自动收取好友的转账
+func TransferFromFriends() TransferPolicy {
+	return func(msg *Message) bool {
+		return msg.meta != nil && msg.IsSendByFriend()
+	}
+}
+
+// TransferFrom 仅自动收取指定 wxid 的转账，XML 中没有付款人时取消息发送者
+func TransferFrom(wxids ...string) TransferPolicy {
+	allowed := make(map[string]struct{}, len(wxids))
+	for _, wxid := range wxids {
+		allowed[wxid] = struct{}{}
+	}
+	return func(msg *Message) bool {
+		payer := msg.Transfer.Payer
+		if payer == "" {
+			payer = msg.WxId
+		}
+		_, ok := allowed[payer]
+		return ok
+	}
+}
+
+// TransferMaxAmount 仅自动收取不超过 amount（分）的转账，金额无法解析时不收款
+func TransferMaxAmount(amount int64) TransferPolicy {
+	return func(msg *Message) bool {
+		return msg.Transfer.AmountKnown && msg.Transfer.Amount <= amount
+	}
+}
+
+// wcPayInfo 转账 XML 中的 wcpayinfo，transcationid 为微信原有拼写
+type wcPayInfo struct {
+	AppMsgType int `xml:"appmsg>type"`
+	PayInfo    struct {
+		PaySubType    int    `xml:"paysubtype"`
+		FeeDesc       string `xml:"feedesc"`
+		TransactionId string `xml:"transcationid"`
+		TransferId    string `xml:"transferid"`
+		PayMemo       string `xml:"pay_memo"`
+		Receiver      string `xml:"receiver_username"`
+		Payer         string `xml:"payer_username"`
+	} `xml:"appmsg>wcpayinfo"`
+}
+
+// parseTransferMsg 解析 appmsg type 为 2000 的转账消息，其他消息返回 nil
+func parseTransferMsg(content string) (*TransferMsg, error) {
+	var info wcPayInfo
+	if err := xml.Unmarshal([]byte(content), &info); err != nil {
+		return nil, fmt.Errorf("xml.Unmarshal transfer: %w", err)
+	}
+	if info.AppMsgType != 2000 {
+		return nil, nil
+	}
+	p := info.PayInfo
+	t := &TransferMsg{
+		AmountText:    strings.TrimSpace(p.FeeDesc),
+		Memo:          p.PayMemo,
+		TransferId:    p.TransferId,
+		TransactionId: p.TransactionId,
+		Status:        TransferStatus(p.PaySubType),
+		Payer:         p.Payer,
+		Receiver:      p.Receiver,
+	}
+	t.Amount, t.AmountKnown = parseYuan(t.AmountText)
+	return t, nil
+}
+
+// parseYuan 将 ￥12.3 形式的金额转为分
+func parseYuan(s string) (int64, bool) {
+	s = strings.TrimLeftFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	yuan, fen, _ := strings.Cut(s, ".")
+	if yuan == "" && fen == "" {
+		return 0, false
+	}
+	y, err := strconv.ParseInt("0"+yuan, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	fen = (fen + "00")[:2]
+	f, err := strconv.ParseInt(fen, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return y*100 + f, true
+}
+
+// AcceptTransfer 收取转账消息中的转账
+func (m *Message) AcceptTransfer() error {
+	if m.Transfer == nil {
+		return ErrNotTransfer
+	}
+	if m.IsSelf || m.Transfer.Status != TransferPending {
+		return ErrTransferNotPending
+	}
+	return m.meta.AcceptTransfer(*m.Transfer)
+}
+
+// ReceiveTransfer 收取转账 <付款人 wxid> <transferid> <transactionid>
+func (c *Client) ReceiveTransfer(wxid, transferId, transactionId string) error {
+	return c.ReceiveTransferCtx(context.Background(), wxid, transferId, transactionId)
+}
+
+// ReceiveTransferCtx 同 ReceiveTransfer，支持 ctx 取消与超时
+func (c *Client) ReceiveTransferCtx(ctx context.Context, wxid, transferId, transactionId string) error {
+	if _, err := c.wxClient.ReceiveTransferCtx(ctx, wxid, transferId, transactionId); err != nil {
+		return fmt.Errorf("wxClient.ReceiveTransfer: %w", err)
+	}
+	return nil
+}
+
+// autoAcceptTransfer 所有自动收款策略均同意时收取发给自己的待收款转账
+func (c *Client) autoAcceptTransfer(m *Message) {
+	t := m.Transfer
+	if len(c.transferPolicies) == 0 || m.IsSelf || t.Status != TransferPending {
+		return
+	}
+	if self, ok := c.GetSelfWxId(); ok && t.Receiver != "" && t.Receiver != self {
+		return
+	}
+	for _, policy := range c.transferPolicies {
+		if !policy(m) {
+			return
+		}
+	}
+	if err := m.AcceptTransfer(); err != nil {
+		c.logger.Warn(err, "auto accept transfer", map[string]interface{}{"messageId": m.MessageId, "transferId": t.TransferId})
+		return
+	}
+	t.AutoAccepted = true
+}
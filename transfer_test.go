@@ -0,0 +1,101 @@
+package wcf_rpc_sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Clov614/wcf-rpc-sdk/internal/wcf"
+	"github.com/Clov614/wcf-rpc-sdk/internal/wcftest"
+	"strings"
+	"testing"
+)
+
+func transferXml(fee string, subType int, tfid string) string {
+	return fmt.Sprintf(`<msg><appmsg appid="" sdkver=""><title><![CDATA[微信转账]]></title><type>2000</type>`+
+		`<wcpayinfo><paysubtype>%d</paysubtype><feedesc><![CDATA[%s]]></feedesc>`+
+		`<transcationid><![CDATA[ta_%s]]></transcationid><transferid><![CDATA[%s]]></transferid>`+
+		`<pay_memo><![CDATA[午饭]]></pay_memo><receiver_username><![CDATA[wxid_self]]></receiver_username>`+
+		`<payer_username><![CDATA[wxid_friend]]></payer_username></wcpayinfo></appmsg></msg>`, subType, fee, tfid, tfid)
+}
+
+func TestParseTransferMsg(t *testing.T) {
+	got, err := parseTransferMsg(transferXml("￥12.3", 1, "tf1"))
+	if err != nil {
+		t.Fatalf("parseTransferMsg() error = %v", err)
+	}
+	want := TransferMsg{Amount: 1230, AmountKnown: true, AmountText: "￥12.3", Memo: "午饭", TransferId: "tf1", TransactionId: "ta_tf1",
+		Status: TransferPending, Payer: "wxid_friend", Receiver: "wxid_self"}
+	if *got != want {
+		t.Errorf("parseTransferMsg() = %+v, want %+v", *got, want)
+	}
+	if got, err = parseTransferMsg(`<msg><appmsg><type>5</type></appmsg></msg>`); got != nil || err != nil {
+		t.Errorf("parseTransferMsg(link) = %+v, %v, want nil", got, err)
+	}
+
+	if got, err = parseTransferMsg(transferXml("￥1,000.00", 1, "tf2")); err != nil || got.AmountKnown || got.Amount != 0 {
+		t.Errorf("parseTransferMsg(malformed) = %+v, %v, want unknown amount", got, err)
+	}
+
+	for s, want := range map[string]int64{"￥0.01": 1, "￥100.00": 10000, "￥5": 500, ".5": 50} {
+		if got, ok := parseYuan(s); got != want || !ok {
+			t.Errorf("parseYuan(%q) = %d, %v, want %d", s, got, ok, want)
+		}
+	}
+	for _, s := range []string{"", "￥", "￥abc", "￥1.2x", "￥1,000.00"} {
+		if _, ok := parseYuan(s); ok {
+			t.Errorf("parseYuan(%q) ok, want malformed", s)
+		}
+	}
+}
+
+func TestClient_TransferPolicy(t *testing.T) {
+	srv, err := wcftest.NewServer()
+	if err != nil {
+		t.Fatalf("wcftest.NewServer() error = %v", err)
+	}
+	t.Cleanup(func() { _ = srv.Close() })
+	srv.SetUserInfo(&wcf.UserInfo{Wxid: "wxid_self"})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if _, err = New(ctx, WithAddr(srv.Addr()), WithTransferPolicy(nil)); !errors.Is(err, ErrInvalidOption) {
+		t.Fatalf("New(nil policy) error = %v, want ErrInvalidOption", err)
+	}
+	cli, err := New(ctx, WithAddr(srv.Addr()), WithTransferPolicy(TransferFrom("wxid_friend"), TransferMaxAmount(100)))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer cli.Close()
+	if err = cli.Run(false); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	noPayer := strings.Replace(transferXml("￥0.20", 1, "nopayer"), "<payer_username><![CDATA[wxid_friend]]></payer_username>", "", 1)
+	contents := []string{transferXml("￥0.50", 1, "small"), transferXml("￥2.00", 1, "large"), transferXml("￥0.50", 3, "done"),
+		transferXml("￥0.5元", 1, "malformed"), noPayer}
+	got := make(map[string]*Message)
+	for i, content := range contents {
+		_ = srv.Push(&wcf.WxMsg{Id: uint64(i + 1), Type: uint32(MsgTypeXML), Sender: "wxid_friend", Content: content})
+		msg := recvMsg(t, cli)
+		if msg.Type != MsgTypeXMLTransfer || msg.Transfer == nil {
+			t.Fatalf("message %d = %+v, want transfer", i+1, msg)
+		}
+		got[msg.Transfer.TransferId] = msg
+	}
+	for tfid, want := range map[string]bool{"small": true, "large": false, "done": false, "malformed": false, "nopayer": true} {
+		if got[tfid].Transfer.AutoAccepted != want {
+			t.Errorf("%s auto accepted = %v, want %v", tfid, got[tfid].Transfer.AutoAccepted, want)
+		}
+	}
+
+	if err = got["large"].AcceptTransfer(); err != nil {
+		t.Errorf("AcceptTransfer() error = %v", err)
+	}
+	if err = got["done"].AcceptTransfer(); !errors.Is(err, ErrTransferNotPending) {
+		t.Errorf("AcceptTransfer(received) error = %v, want ErrTransferNotPending", err)
+	}
+	reqs := srv.RequestsOf(wcf.Functions_FUNC_RECV_TRANSFER)
+	if len(reqs) != 3 || reqs[0].GetTf().GetTfid() != "small" || reqs[1].GetTf().GetTfid() != "nopayer" || reqs[1].GetTf().GetWxid() != "wxid_friend" ||
+		reqs[2].GetTf().GetTaid() != "ta_large" || reqs[2].GetTf().GetWxid() != "wxid_friend" {
+		t.Errorf("transfer requests = %v", reqs)
+	}
+}
@@ -0,0 +1,63 @@
+// Package wcf_rpc_sdk
+// @Author Clover
+// @Data 2026/10/17 上午4:40:00
+// @Desc 视频、小视频消息解析与发送
+package wcf_rpc_sdk
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var ErrNotMP4 = errors.New("only mp4 video is supported")
+
+// VideoMsg 视频消息，视频文件需通过 Attachments().Download 下载后才存在
+type VideoMsg struct {
+	Duration  time.Duration `json:"duration"`             // 播放时长
+	Size      int64         `json:"size"`                 // 视频大小，单位字节
+	MD5       string        `json:"md5,omitempty"`        // 视频 md5
+	ThumbPath string        `json:"thumb_path,omitempty"` // 封面缩略图的本地路径，随消息写入
+	VideoPath string        `json:"video_path,omitempty"` // 视频的本地路径
+}
+
+// videoXml 视频消息的 videomsg
+type videoXml struct {
+	VideoMsg struct {
+		Length     int64  `xml:"length,attr"`
+		PlayLength int    `xml:"playlength,attr"`
+		MD5        string `xml:"md5,attr"`
+	} `xml:"videomsg"`
+}
+
+// parseVideoMsg 解析视频消息 <xml 内容> <缩略图路径> <视频路径>
+func parseVideoMsg(content, thumb, extra string) (*VideoMsg, error) {
+	var vx videoXml
+	if err := xml.Unmarshal([]byte(content), &vx); err != nil {
+		return nil, fmt.Errorf("xml.Unmarshal video: %w", err)
+	}
+	return &VideoMsg{
+		Duration:  time.Duration(vx.VideoMsg.PlayLength) * time.Second,
+		Size:      vx.VideoMsg.Length,
+		MD5:       vx.VideoMsg.MD5,
+		ThumbPath: filepath.ToSlash(thumb),
+		VideoPath: filepath.ToSlash(extra),
+	}, nil
+}
+
+// SendVideo 发送 mp4 视频 <wxid or roomid> <视频绝对路径>
+func (c *Client) SendVideo(receiver string, src string) error {
+	return c.SendVideoCtx(context.Background(), receiver, src)
+}
+
+// SendVideoCtx 同 SendVideo，支持 ctx 取消与超时；微信按文件发送 mp4 时会显示为视频
+func (c *Client) SendVideoCtx(ctx context.Context, receiver string, src string) error {
+	if !strings.EqualFold(filepath.Ext(src), ".mp4") {
+		return fmt.Errorf("%w: %s", ErrNotMP4, src)
+	}
+	return c.SendFileCtx(ctx, receiver, src)
+}
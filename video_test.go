@@ -0,0 +1,72 @@
+package wcf_rpc_sdk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/Clov614/wcf-rpc-sdk/internal/wcf"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const videoContent = `<?xml version="1.0"?>
+<msg>
+	<videomsg aeskey="0123" cdnvideourl="3057020100" cdnthumbaeskey="0123" cdnthumburl="3057020100" length="2395610" playlength="12" cdnthumblength="10234" cdnthumbwidth="224" cdnthumbheight="398" fromusername="wxid_friend" md5="9f6e7a6c1b2d3e4f5a6b7c8d9e0f1a2b" newmd5="" isplaceholder="0" rawmd5="" rawlength="0" cdnrawvideourl="" cdnrawvideoaeskey="" overwritenewmsgid="0" originsourcemd5="" isad="0" />
+</msg>`
+
+func TestParseVideoMsg(t *testing.T) {
+	got, err := parseVideoMsg(videoContent, `C:\WeChat Files\wxid_self\FileStorage\Video\2026-10\abc.jpg`, `C:\WeChat Files\wxid_self\FileStorage\Video\2026-10\abc.mp4`)
+	if err != nil {
+		t.Fatalf("parseVideoMsg() error = %v", err)
+	}
+	want := VideoMsg{
+		Duration:  12 * time.Second,
+		Size:      2395610,
+		MD5:       "9f6e7a6c1b2d3e4f5a6b7c8d9e0f1a2b",
+		ThumbPath: filepath.ToSlash(`C:\WeChat Files\wxid_self\FileStorage\Video\2026-10\abc.jpg`),
+		VideoPath: filepath.ToSlash(`C:\WeChat Files\wxid_self\FileStorage\Video\2026-10\abc.mp4`),
+	}
+	if *got != want {
+		t.Errorf("parseVideoMsg() = %+v, want %+v", *got, want)
+	}
+}
+
+func TestClient_Video(t *testing.T) {
+	cli, srv := newAttachmentClient(t)
+	if err := cli.Run(false); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	dir := t.TempDir()
+	thumb := []byte{0xFF, 0xD8, 0xFF, 0xE0, 4, 5, 6}
+	srv.Handle(wcf.Functions_FUNC_DOWNLOAD_ATTACH, func(req *wcf.Request) *wcf.Response {
+		_ = os.WriteFile(req.GetAtt().GetThumb(), thumb, 0o644)
+		_ = os.WriteFile(req.GetAtt().GetExtra(), []byte("mp4 data"), 0o644)
+		return nil
+	})
+	_ = srv.Push(&wcf.WxMsg{Id: 11, Type: uint32(MsgTypeShortVideo), Sender: "wxid_friend", Content: videoContent,
+		Thumb: filepath.Join(dir, "abc.jpg"), Extra: filepath.Join(dir, "abc.mp4")})
+	msg := recvMsg(t, cli)
+	if msg.Video == nil || msg.Video.Duration != 12*time.Second || msg.Video.VideoPath != filepath.ToSlash(filepath.Join(dir, "abc.mp4")) {
+		t.Fatalf("Video = %+v", msg.Video)
+	}
+	if n := len(srv.RequestsOf(wcf.Functions_FUNC_DOWNLOAD_ATTACH)); n != 0 {
+		t.Errorf("download requests before Download = %d, want 0", n)
+	}
+	res := recvResult(t, cli.Attachments().Download(context.Background(), msg))
+	if res.Err != nil || res.Path != msg.Video.VideoPath || res.FileExt != ".mp4" || !bytes.Equal(res.Data, thumb) {
+		t.Fatalf("Download(video) = %+v", res)
+	}
+
+	if err := cli.SendVideo("wxid_friend", "C:/videos/a.MP4"); err != nil {
+		t.Fatalf("SendVideo() error = %v", err)
+	}
+	reqs := srv.RequestsOf(wcf.Functions_FUNC_SEND_FILE)
+	if len(reqs) != 1 || reqs[0].GetFile().GetPath() != "C:/videos/a.MP4" || reqs[0].GetFile().GetReceiver() != "wxid_friend" {
+		t.Fatalf("send file requests = %v", reqs)
+	}
+	if err := cli.SendVideo("wxid_friend", "C:/videos/a.avi"); !errors.Is(err, ErrNotMP4) {
+		t.Errorf("SendVideo(avi) error = %v, want ErrNotMP4", err)
+	}
+}
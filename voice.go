@@ -0,0 +1,170 @@
+// Package wcf_rpc_sdk
+// @Author Clover
+// @Data 2026/10/16 下午11:40:00
+// @Desc 语音消息获取与 silk 格式解析
+package wcf_rpc_sdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultVoiceTimeout = 10 * time.Second // ctx 未设置 deadline 时等待语音入库的时间
+	silkFrameDuration   = 20 * time.Millisecond
+)
+
+var (
+	ErrNotVoice      = errors.New("the message is not a voice")
+	ErrVoiceNotReady = errors.New("voice not found in media database") // 超时前语音仍未写入 MediaMSG 数据库
+	ErrInvalidSilk   = errors.New("invalid silk data")
+)
+
+var silkHeader = []byte("#!SILK_V3")
+
+// SilkInfo silk v3 文件信息
+type SilkInfo struct {
+	Tencent  bool          `json:"tencent"`  // 以 0x02 开头的微信格式
+	Frames   int           `json:"frames"`   // 数据包个数
+	Duration time.Duration `json:"duration"` // 时长，按每包 20ms 估算
+}
+
+// ParseSilk 校验 silk v3 文件头并统计数据包，每包为 2 字节小端长度 + 数据，长度为负数或到达末尾时结束
+func ParseSilk(data []byte) (*SilkInfo, error) {
+	info := &SilkInfo{}
+	if len(data) > 0 && data[0] == 0x02 {
+		info.Tencent = true
+		data = data[1:]
+	}
+	if !bytes.HasPrefix(data, silkHeader) {
+		return nil, fmt.Errorf("%w: missing header", ErrInvalidSilk)
+	}
+	data = data[len(silkHeader):]
+	for len(data) >= 2 {
+		size := int16(binary.LittleEndian.Uint16(data))
+		if size < 0 {
+			break
+		}
+		data = data[2:]
+		if int(size) > len(data) {
+			return nil, fmt.Errorf("%w: frame %d truncated", ErrInvalidSilk, info.Frames)
+		}
+		data = data[size:]
+		info.Frames++
+	}
+	info.Duration = time.Duration(info.Frames) * silkFrameDuration
+	return info, nil
+}
+
+// GetVoice 从 MediaMSG 数据库读取语音消息的 silk 数据，保存为 <dir>/<消息 id>.silk 并返回路径
+func (c *Client) GetVoice(msg *Message, dir string) (string, error) {
+	return c.GetVoiceCtx(context.Background(), msg, dir)
+}
+
+// GetVoiceCtx 同 GetVoice，语音可能晚于消息入库，未找到时按间隔重试直到 ctx 结束（未设置 deadline 时为 DefaultVoiceTimeout）
+func (c *Client) GetVoiceCtx(ctx context.Context, msg *Message, dir string) (string, error) {
+	if msg == nil || msg.Type != MsgTypeVoice {
+		return "", ErrNotVoice
+	}
+	ctx, cancel := voiceContext(ctx)
+	defer cancel()
+	data, err := c.voiceSilk(ctx, msg.MessageId, voicePollInterval)
+	if err != nil {
+		return "", err
+	}
+	return saveSilk(data, dir, msg.MessageId)
+}
+
+// GetVoiceMP3 由微信所在主机通过 FUNC_GET_AUDIO_MSG 将语音转为 MP3 保存到 dir（该主机上的已存在目录），返回文件路径
+func (c *Client) GetVoiceMP3(msg *Message, dir string) (string, error) {
+	return c.GetVoiceMP3Ctx(context.Background(), msg, dir)
+}
+
+// GetVoiceMP3Ctx 同 GetVoiceMP3，语音尚未入库时按间隔重试直到 ctx 结束（未设置 deadline 时为 DefaultVoiceTimeout）
+func (c *Client) GetVoiceMP3Ctx(ctx context.Context, msg *Message, dir string) (string, error) {
+	if msg == nil || msg.Type != MsgTypeVoice {
+		return "", ErrNotVoice
+	}
+	ctx, cancel := voiceContext(ctx)
+	defer cancel()
+	interval := voicePollInterval
+	for {
+		path, err := c.wxClient.GetAudioMsgCtx(ctx, msg.MessageId, dir)
+		if err != nil {
+			return "", fmt.Errorf("wxClient.GetAudioMsg: %w", err)
+		}
+		if path != "" {
+			return path, nil
+		}
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("%w: voice %d: %w", ErrVoiceNotReady, msg.MessageId, ctx.Err())
+		case <-time.After(interval):
+		}
+	}
+}
+
+// voiceContext ctx 未设置 deadline 时使用 DefaultVoiceTimeout
+func voiceContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, DefaultVoiceTimeout)
+}
+
+// voiceSilk 按消息 id 从 MediaMSG 数据库读取语音的 silk 数据，语音可能晚于消息入库，未找到时按 interval 重试直到 ctx 结束
+func (c *Client) voiceSilk(ctx context.Context, msgId uint64, interval time.Duration) ([]byte, error) {
+	names, err := c.wxClient.GetDBNamesCtx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("wxClient.GetDBNames: %w", err)
+	}
+	var dbs []string
+	for _, name := range names {
+		if strings.HasPrefix(name, "MediaMSG") {
+			dbs = append(dbs, name)
+		}
+	}
+	sql := fmt.Sprintf("SELECT Buf FROM Media WHERE Reserved0 = %d;", msgId)
+	for {
+		for _, db := range dbs {
+			rows, err := c.wxClient.ExecDBQueryCtx(ctx, db, sql)
+			if err != nil {
+				return nil, fmt.Errorf("wxClient.ExecDBQuery: %w", err)
+			}
+			for _, row := range rows {
+				for _, field := range row.GetFields() {
+					if field.GetColumn() == "Buf" && len(field.GetContent()) > 0 {
+						return field.GetContent(), nil
+					}
+				}
+			}
+		}
+		c.logger.Debug("voice not ready", map[string]interface{}{"messageId": msgId, "dbs": dbs})
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: voice %d: %w", ErrVoiceNotReady, msgId, ctx.Err())
+		case <-time.After(interval):
+		}
+	}
+}
+
+func saveSilk(data []byte, dir string, msgId uint64) (string, error) {
+	if _, err := ParseSilk(data); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("create voice dir: %w", err)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%d.silk", msgId))
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("write voice: %w", err)
+	}
+	return path, nil
+}
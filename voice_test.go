@@ -0,0 +1,114 @@
+package wcf_rpc_sdk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/Clov614/wcf-rpc-sdk/internal/wcf"
+	"github.com/Clov614/wcf-rpc-sdk/internal/wcftest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// silkData 构造 silk 数据：<0x02> #!SILK_V3 + 各包 + 结束标记
+func silkData(tencent bool, frames ...[]byte) []byte {
+	var buf bytes.Buffer
+	if tencent {
+		buf.WriteByte(0x02)
+	}
+	buf.Write(silkHeader)
+	for _, frame := range frames {
+		buf.Write([]byte{byte(len(frame)), byte(len(frame) >> 8)})
+		buf.Write(frame)
+	}
+	buf.Write([]byte{0xff, 0xff})
+	return buf.Bytes()
+}
+
+func newVoiceClient(t *testing.T) (*Client, *wcftest.Server) {
+	t.Helper()
+	interval := voicePollInterval
+	voicePollInterval = 20 * time.Millisecond
+	t.Cleanup(func() { voicePollInterval = interval })
+	return newOfflineClient(t)
+}
+
+func TestParseSilk(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    SilkInfo
+		wantErr bool
+	}{
+		{"tencent", silkData(true, []byte{1, 2, 3}, []byte{4}, nil), SilkInfo{Tencent: true, Frames: 3, Duration: 60 * time.Millisecond}, false},
+		{"plain", silkData(false, []byte{1, 2}), SilkInfo{Frames: 1, Duration: 20 * time.Millisecond}, false},
+		{"no terminator", append(append([]byte{}, silkHeader...), 1, 0, 9), SilkInfo{Frames: 1, Duration: 20 * time.Millisecond}, false},
+		{"truncated", append(append([]byte{}, silkHeader...), 5, 0, 9), SilkInfo{}, true},
+		{"no header", []byte("ID3\x03"), SilkInfo{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSilk(tt.data)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidSilk) {
+					t.Errorf("ParseSilk() error = %v, want ErrInvalidSilk", err)
+				}
+				return
+			}
+			if err != nil || *got != tt.want {
+				t.Errorf("ParseSilk() = %+v, %v, want %+v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_GetVoice(t *testing.T) {
+	cli, srv := newVoiceClient(t)
+	data := silkData(true, []byte{1, 2, 3})
+	srv.SetDBTables("MediaMSG0.db")
+	srv.SetQuery("MediaMSG0.db", "SELECT Buf FROM Media WHERE Reserved0 = 42;", &wcf.DbRow{Fields: []*wcf.DbField{{Type: 4, Column: "Buf", Content: data}}})
+
+	dir := t.TempDir()
+	path, err := cli.GetVoice(&Message{MessageId: 42, Type: MsgTypeVoice}, dir)
+	if err != nil {
+		t.Fatalf("GetVoice() error = %v", err)
+	}
+	if path != filepath.Join(dir, "42.silk") {
+		t.Errorf("GetVoice() path = %s", path)
+	}
+	if saved, _ := os.ReadFile(path); !bytes.Equal(saved, data) {
+		t.Errorf("saved silk = %v, want %v", saved, data)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err = cli.GetVoiceCtx(ctx, &Message{MessageId: 43, Type: MsgTypeVoice}, dir); !errors.Is(err, ErrVoiceNotReady) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetVoiceCtx() error = %v, want ErrVoiceNotReady", err)
+	}
+	if _, err = cli.GetVoiceCtx(ctx, &Message{MessageId: 42, Type: MsgTypeText}, dir); !errors.Is(err, ErrNotVoice) {
+		t.Errorf("GetVoiceCtx() error = %v, want ErrNotVoice", err)
+	}
+}
+
+func TestClient_GetVoiceMP3(t *testing.T) {
+	cli, srv := newVoiceClient(t)
+	calls := 0
+	srv.Handle(wcf.Functions_FUNC_GET_AUDIO_MSG, func(req *wcf.Request) *wcf.Response {
+		if calls++; calls == 1 { // 首次调用时语音尚未入库
+			return &wcf.Response{Msg: &wcf.Response_Str{Str: ""}}
+		}
+		return &wcf.Response{Msg: &wcf.Response_Str{Str: req.GetAm().GetDir() + `\42.mp3`}}
+	})
+	path, err := cli.GetVoiceMP3(&Message{MessageId: 42, Type: MsgTypeVoice}, `C:\voice`)
+	if err != nil || path != `C:\voice\42.mp3` {
+		t.Fatalf("GetVoiceMP3() = %s, %v", path, err)
+	}
+	if reqs := srv.RequestsOf(wcf.Functions_FUNC_GET_AUDIO_MSG); len(reqs) != 2 || reqs[0].GetAm().GetId() != 42 {
+		t.Errorf("audio requests = %v", reqs)
+	}
+	if _, err = cli.GetVoiceMP3(nil, `C:\voice`); !errors.Is(err, ErrNotVoice) {
+		t.Errorf("GetVoiceMP3(nil) error = %v, want ErrNotVoice", err)
+	}
+}